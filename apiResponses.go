@@ -16,3 +16,36 @@ type BasicAPIResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
+
+// HealthResponse is the standard response for any
+// service's liveness and readiness endpoints
+type HealthResponse struct {
+	Status  string        `json:"status"`
+	Version string        `json:"version"`
+	Uptime  time.Duration `json:"uptime"`
+	Checks  []CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the result of a single named health check,
+// such as whether the database or steam API is reachable
+type CheckStatus struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Critical bool          `json:"critical"`
+	Latency  time.Duration `json:"latency"`
+	// LastError is kept after a check recovers so
+	// intermittent failures can still be seen
+	LastError     string `json:"lasterror,omitempty"`
+	LastErrorTime int64  `json:"lasterrortime,omitempty"`
+}
+
+// VersionResponse is the standard response for any service's
+// /version endpoint, describing what the service was built from
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"goversion"`
+	Module    string `json:"module"`
+}
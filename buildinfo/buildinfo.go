@@ -0,0 +1,74 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// Version, Commit and Date are injected at build time, for example:
+//
+//	go build -ldflags "-X github.com/neosteamfriendgraphing/common/buildinfo.Version=v1.2.0 \
+//		-X github.com/neosteamfriendgraphing/common/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X github.com/neosteamfriendgraphing/common/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When they are not set the values recorded by the go toolchain are used
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// readBuildInfo is replaced in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// Get returns information about the build of the running binary. Values
+// injected by the linker take priority over those from debug.ReadBuildInfo
+func Get() common.VersionResponse {
+	info := common.VersionResponse{
+		GoVersion: runtime.Version(),
+	}
+
+	if buildInfo, ok := readBuildInfo(); ok {
+		info.Module = buildInfo.Main.Path
+		if buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.Date = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		info.Version = Version
+	}
+	if Commit != "" {
+		info.Commit = Commit
+	}
+	if Date != "" {
+		info.Date = Date
+	}
+	if info.Version == "" {
+		info.Version = "unknown"
+	}
+	return info
+}
+
+// Handler serves Get as JSON, this is used for each service's /version endpoint
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Get())
+	})
+}
@@ -0,0 +1,68 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeBuildInfo() (*debug.BuildInfo, bool) {
+	return &debug.BuildInfo{
+		Main: debug.Module{
+			Path:    "github.com/neosteamfriendgraphing/neo",
+			Version: "(devel)",
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "4b825dc642cb6eb9a060e54bf8d69288fbee4904"},
+			{Key: "vcs.time", Value: "2021-11-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}, true
+}
+
+func TestGetUsesToolchainBuildInfo(t *testing.T) {
+	readBuildInfo = fakeBuildInfo
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	info := Get()
+
+	assert.Equal(t, "unknown", info.Version)
+	assert.Equal(t, "4b825dc642cb6eb9a060e54bf8d69288fbee4904", info.Commit)
+	assert.Equal(t, "2021-11-01T12:00:00Z", info.Date)
+	assert.True(t, info.Modified)
+	assert.Equal(t, "github.com/neosteamfriendgraphing/neo", info.Module)
+	assert.NotEmpty(t, info.GoVersion)
+}
+
+func TestGetPrefersLinkerInjectedValues(t *testing.T) {
+	readBuildInfo = fakeBuildInfo
+	Version, Commit, Date = "v1.2.0", "abc123", "2021-12-25T00:00:00Z"
+	defer func() {
+		readBuildInfo = debug.ReadBuildInfo
+		Version, Commit, Date = "", "", ""
+	}()
+
+	info := Get()
+
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2021-12-25T00:00:00Z", info.Date)
+}
+
+func TestHandlerServesVersionResponse(t *testing.T) {
+	Version = "v1.2.0"
+	defer func() { Version = "" }()
+
+	res := httptest.NewRecorder()
+	Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	response := common.VersionResponse{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "v1.2.0", response.Version)
+}
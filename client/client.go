@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/neosteamfriendgraphing/common/util"
+)
+
+const (
+	// DefaultAuthHeader is the header the auth key is sent in
+	DefaultAuthHeader = "Authentication"
+	// RequestIDHeader is the header request IDs are forwarded in
+	RequestIDHeader = util.RequestIDHeader
+
+	// DefaultTimeout is how long a single attempt at a request can take
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxRetries is how many times a failed request is retried
+	DefaultMaxRetries = 3
+	// DefaultRetryBackoff is the wait before the first retry, it
+	// doubles for each retry after that
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// maxErrorBodySize is how much of an error response is read
+	maxErrorBodySize = 64 * 1024
+)
+
+var (
+	// ErrInvalidInput is matched by errors.Is for 400 responses
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUnauthorized is matched by errors.Is for 401 and 403 responses
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is matched by errors.Is for 404 responses
+	ErrNotFound = errors.New("not found")
+	// ErrUnavailable is matched by errors.Is for 429 and 5xx responses
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// APIError is returned when a service responds with a non 2xx status code
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is the error returned by the service, or the
+	// response body if it was not the standard error response
+	Message string
+	// Fields are the invalid fields of a request rejected
+	// by util.DecodeAndValidate
+	Fields []util.FieldError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// Unwrap allows errors.Is to match an APIError against ErrInvalidInput,
+// ErrUnauthorized, ErrNotFound and ErrUnavailable
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrInvalidInput
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// Option configures a Client created by New
+type Option func(*Client)
+
+// WithHTTPClient sends requests with an existing http.Client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuthKey sets the auth key sent with every request, defaults to AUTH_KEY
+func WithAuthKey(authKey string) Option {
+	return func(c *Client) {
+		c.authKey = authKey
+	}
+}
+
+// WithAuthHeader sets the header the auth key is sent in
+func WithAuthHeader(header string) Option {
+	return func(c *Client) {
+		c.authHeader = header
+	}
+}
+
+// WithRetries sets how many times requests that can be safely repeated are
+// retried after a network error, 429 or 5xx response and how long to wait
+// before the first retry. WithRetries(0, 0) disables retries
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// Client calls the endpoints of a Neo service
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	authKey      string
+	authHeader   string
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// New creates a Client for the service at baseURL, such as
+// http://datastore:2590
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: DefaultTimeout},
+		authKey:      os.Getenv("AUTH_KEY"),
+		authHeader:   DefaultAuthHeader,
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context that forwards requestID to every
+// service called with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// call describes a single call to an endpoint
+type call struct {
+	method string
+	path   string
+	input  interface{}
+	output interface{}
+	// retry is set for calls that have no side effects and so are
+	// safe to send more than once, including lookups sent as POST
+	retry bool
+}
+
+// validationError is returned when an input fails validation before it's
+// sent, it matches ErrInvalidInput and unwraps to the validation errors
+type validationError struct {
+	err error
+}
+
+func (e validationError) Error() string {
+	return fmt.Sprintf("invalid input: %s", e.err)
+}
+
+func (e validationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+func (e validationError) Unwrap() error {
+	return e.err
+}
+
+// do sends a call, retrying it if it's safe to do so, and
+// decodes the response into call.output
+func (c *Client) do(ctx context.Context, req call) error {
+	if validator, ok := req.input.(util.Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return validationError{err: err}
+		}
+	}
+	var body []byte
+	if req.input != nil {
+		encoded, err := json.Marshal(req.input)
+		if err != nil {
+			return fmt.Errorf("could not encode request: %w", err)
+		}
+		body = encoded
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := c.attempt(ctx, req, body)
+		if err == nil || !req.retry || !retryable || attempt >= c.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// attempt sends a call once. When it fails, retryable is whether
+// sending it again could succeed
+func (c *Client) attempt(ctx context.Context, req call, body []byte) (bool, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, bodyReader)
+	if err != nil {
+		return false, fmt.Errorf("could not create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.authKey != "" {
+		httpReq.Header.Set(c.authHeader, c.authKey)
+	}
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		httpReq.Header.Set(RequestIDHeader, requestID)
+	}
+
+	res, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return true, fmt.Errorf("%s %s failed: %w", req.method, req.path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := newAPIError(req, res)
+		return errors.Is(apiErr, ErrUnavailable), apiErr
+	}
+	if req.output == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(req.output); err != nil {
+		return false, fmt.Errorf("could not decode response from %s %s: %w", req.method, req.path, err)
+	}
+	return false, nil
+}
+
+func newAPIError(req call, res *http.Response) *APIError {
+	apiErr := &APIError{
+		Method:     req.method,
+		Path:       req.path,
+		StatusCode: res.StatusCode,
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	response := struct {
+		Error  string            `json:"error"`
+		Fields []util.FieldError `json:"fields"`
+	}{}
+	if err := json.Unmarshal(body, &response); err == nil && response.Error != "" {
+		apiErr.Message = response.Error
+		apiErr.Fields = response.Fields
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(res.StatusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+	"github.com/neosteamfriendgraphing/common/openapi"
+	"github.com/neosteamfriendgraphing/common/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSteamID       = "76561197960287930"
+	testSecondSteamID = "76561197960287931"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *int32) {
+	calls := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(calls, 1)
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return New(server.URL+"/", WithAuthKey("testkey"), WithRetries(2, time.Millisecond)), calls
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestStatusSendsAuthAndRequestID(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/status", req.URL.Path)
+		assert.Equal(t, "testkey", req.Header.Get(DefaultAuthHeader))
+		assert.Equal(t, "request-1", req.Header.Get(RequestIDHeader))
+		writeJSON(w, http.StatusOK, common.UptimeResponse{Status: "success"})
+	})
+
+	ctx := WithRequestID(context.Background(), "request-1")
+	status, err := client.Status(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", status.Status)
+}
+
+func TestCrawlSendsInput(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/crawl", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Empty(t, req.Header.Get(RequestIDHeader))
+		input := dtos.CrawlUsersInputDTO{}
+		assert.Nil(t, util.DecodeAndValidate(w, req, &input, 0))
+		assert.Equal(t, testSteamID, input.FirstSteamID)
+		writeJSON(w, http.StatusOK, common.BasicAPIResponse{Status: "success", Message: "crawling"})
+	})
+
+	res, err := client.Crawl(context.Background(), dtos.CrawlUsersInputDTO{FirstSteamID: testSteamID, Level: 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "crawling", res.Message)
+}
+
+func TestRetriesUnavailableResponses(t *testing.T) {
+	var attempts int32
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, common.BasicAPIResponse{Status: "error", Message: "busy"})
+			return
+		}
+		writeJSON(w, http.StatusOK, dtos.GetSharedGamesDTO{Status: "success"})
+	})
+
+	shared, err := client.GetSharedGames(context.Background(), dtos.GetSharedGamesInputDTO{FirstSteamID: testSteamID, SecondSteamID: testSecondSteamID})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "success", shared.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetriesAreLimited(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.Status(context.Background())
+
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestCallsWithSideEffectsAreNotRetried(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := client.Crawl(context.Background(), dtos.CrawlUsersInputDTO{FirstSteamID: testSteamID, Level: 1})
+
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "no such crawl", http.StatusNotFound)
+	})
+
+	_, err := client.GetShortestPaths(context.Background(), dtos.GetShortestPathsInputDTO{CrawlID: "missing", FirstSteamID: testSteamID, SecondSteamID: testSecondSteamID})
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	apiErr := &APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "/getshortestpaths", apiErr.Path)
+	assert.Equal(t, "no such crawl", apiErr.Message)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestInvalidInputResponseFields(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":  "invalid input",
+			"fields": []util.FieldError{{Field: "crawlid", Message: "is unknown"}},
+		})
+	})
+
+	_, err := client.CreateGraph(context.Background(), dtos.CreateGraphInputDTO{CrawlID: "crawl"})
+
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	apiErr := &APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "invalid input", apiErr.Message)
+	assert.Equal(t, []util.FieldError{{Field: "crawlid", Message: "is unknown"}}, apiErr.Fields)
+}
+
+func TestUnauthorized(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	_, err := client.Version(context.Background())
+
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.Contains(t, err.Error(), http.StatusText(http.StatusForbidden))
+}
+
+func TestInvalidInputIsNotSent(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, common.BasicAPIResponse{Status: "success"})
+	})
+
+	_, err := client.Crawl(context.Background(), dtos.CrawlUsersInputDTO{FirstSteamID: "invalid", Level: 1})
+
+	assert.True(t, errors.Is(err, ErrInvalidInput))
+	validationErrs := util.ValidationErrors{}
+	assert.True(t, errors.As(err, &validationErrs))
+	assert.Equal(t, int32(0), atomic.LoadInt32(calls))
+}
+
+func TestCancelledContextStopsRetries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client, calls := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.retryBackoff = time.Minute
+
+	_, err := client.Version(ctx)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestPathParametersAreEscaped(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/getmostsimilarfriends/a%2Fb/"+testSteamID, req.URL.EscapedPath())
+		writeJSON(w, http.StatusOK, dtos.GetMostSimilarFriendsDTO{Status: "success"})
+	})
+
+	_, err := client.GetMostSimilarFriends(context.Background(), "a/b", testSteamID)
+
+	assert.Nil(t, err)
+}
+
+// routePattern matches requests to an endpoint, with
+// any value in place of each {name} path parameter
+func routePattern(endpoint openapi.Endpoint) *regexp.Regexp {
+	segments := strings.Split(endpoint.Path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			segments[i] = "[^/]+"
+		} else {
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return regexp.MustCompile("^" + endpoint.Method + " " + strings.Join(segments, "/") + "$")
+}
+
+func TestEveryEndpointIsCalledAsRegistered(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	client, _ := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests = append(requests, req.Method+" "+req.URL.EscapedPath())
+		mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	})
+	ctx := context.Background()
+	saveUser := dtos.SaveUserDTO{OriginalCrawlTarget: testSteamID, CrawlID: "crawl", CurrentLevel: 1, MaxLevel: 2}
+	saveUser.User.AccDetails.SteamID = testSecondSteamID
+	crawlingStats := dtos.SaveCrawlingStatsDTO{CurrentLevel: 1, CrawlingStatus: common.CrawlingStatus{CrawlID: "crawl", OriginalCrawlTarget: testSteamID, MaxLevel: 2}}
+
+	for _, call := range []func() error{
+		func() error { _, err := client.Status(ctx); return err },
+		func() error { _, err := client.Version(ctx); return err },
+		func() error { _, err := client.SaveUser(ctx, saveUser); return err },
+		func() error { _, err := client.GetUser(ctx, testSteamID); return err },
+		func() error { _, err := client.SaveCrawlingStats(ctx, crawlingStats); return err },
+		func() error {
+			_, err := client.Crawl(ctx, dtos.CrawlUsersInputDTO{FirstSteamID: testSteamID, Level: 1})
+			return err
+		},
+		func() error { _, err := client.GetCrawlingStatus(ctx, "crawl"); return err },
+		func() error { _, err := client.GetGraphableData(ctx, testSteamID); return err },
+		func() error {
+			_, err := client.GetUsernamesFromSteamIDs(ctx, dtos.GetUsernamesFromSteamIDsInputDTO{SteamIDs: []string{testSteamID}})
+			return err
+		},
+		func() error {
+			_, err := client.CreateGraph(ctx, dtos.CreateGraphInputDTO{CrawlID: "crawl"})
+			return err
+		},
+		func() error {
+			_, err := client.GetDetailsForGames(ctx, dtos.GetDetailsForGamesInputDTO{GameIDs: []int{10}})
+			return err
+		},
+		func() error {
+			_, err := client.HasBeenCrawledBefore(ctx, dtos.HasBeenCrawledBeforeInputDTO{SteamID: testSteamID, Level: 1})
+			return err
+		},
+		func() error { _, err := client.DoesProcessedGraphDataExist(ctx, "crawl"); return err },
+		func() error { _, err := client.GetProcessedGraphData(ctx, "crawl"); return err },
+		func() error {
+			_, err := client.GetShortestPaths(ctx, dtos.GetShortestPathsInputDTO{CrawlID: "crawl", FirstSteamID: testSteamID, SecondSteamID: testSecondSteamID})
+			return err
+		},
+		func() error {
+			_, err := client.GetSharedGames(ctx, dtos.GetSharedGamesInputDTO{FirstSteamID: testSteamID, SecondSteamID: testSecondSteamID})
+			return err
+		},
+		func() error { _, err := client.GetMostSimilarFriends(ctx, "crawl", testSteamID); return err },
+		func() error { _, err := client.GetRecommendations(ctx, "crawl", testSteamID); return err },
+		func() error { _, err := client.GetNetworkStats(ctx, "crawl"); return err },
+		func() error {
+			_, err := client.GetCrawlDiff(ctx, dtos.GetCrawlDiffInputDTO{OldCrawlID: "a", NewCrawlID: "b"})
+			return err
+		},
+	} {
+		assert.Nil(t, call())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, requests, len(openapi.Endpoints))
+	for _, endpoint := range openapi.Endpoints {
+		pattern := routePattern(endpoint)
+		matched := false
+		for _, request := range requests {
+			matched = matched || pattern.MatchString(request)
+		}
+		assert.True(t, matched, "%s %s is never called", endpoint.Method, endpoint.Path)
+	}
+}
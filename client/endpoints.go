@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+)
+
+// Status calls /status
+func (c *Client) Status(ctx context.Context) (common.UptimeResponse, error) {
+	output := common.UptimeResponse{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/status", output: &output, retry: true})
+	return output, err
+}
+
+// Version calls /version
+func (c *Client) Version(ctx context.Context) (common.VersionResponse, error) {
+	output := common.VersionResponse{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/version", output: &output, retry: true})
+	return output, err
+}
+
+// SaveUser calls /saveuser
+func (c *Client) SaveUser(ctx context.Context, input dtos.SaveUserDTO) (common.BasicAPIResponse, error) {
+	output := common.BasicAPIResponse{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/saveuser", input: &input, output: &output})
+	return output, err
+}
+
+// GetUser calls /getuser/{steamid}
+func (c *Client) GetUser(ctx context.Context, steamID string) (dtos.GetUserDTO, error) {
+	output := dtos.GetUserDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getuser/" + url.PathEscape(steamID), output: &output, retry: true})
+	return output, err
+}
+
+// SaveCrawlingStats calls /savecrawlingstats
+func (c *Client) SaveCrawlingStats(ctx context.Context, input dtos.SaveCrawlingStatsDTO) (common.BasicAPIResponse, error) {
+	output := common.BasicAPIResponse{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/savecrawlingstats", input: &input, output: &output})
+	return output, err
+}
+
+// Crawl calls /crawl
+func (c *Client) Crawl(ctx context.Context, input dtos.CrawlUsersInputDTO) (common.BasicAPIResponse, error) {
+	output := common.BasicAPIResponse{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/crawl", input: &input, output: &output})
+	return output, err
+}
+
+// GetCrawlingStatus calls /getcrawlingstatus/{crawlid}
+func (c *Client) GetCrawlingStatus(ctx context.Context, crawlID string) (dtos.GetCrawlingStatusDTO, error) {
+	output := dtos.GetCrawlingStatusDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getcrawlingstatus/" + url.PathEscape(crawlID), output: &output, retry: true})
+	return output, err
+}
+
+// GetGraphableData calls /getgraphabledata/{steamid}
+func (c *Client) GetGraphableData(ctx context.Context, steamID string) (dtos.GetGraphableDataForUserDTO, error) {
+	output := dtos.GetGraphableDataForUserDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getgraphabledata/" + url.PathEscape(steamID), output: &output, retry: true})
+	return output, err
+}
+
+// GetUsernamesFromSteamIDs calls /getusernamesfromsteamids
+func (c *Client) GetUsernamesFromSteamIDs(ctx context.Context, input dtos.GetUsernamesFromSteamIDsInputDTO) (dtos.GetUsernamesFromSteamIDsDTO, error) {
+	output := dtos.GetUsernamesFromSteamIDsDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getusernamesfromsteamids", input: &input, output: &output, retry: true})
+	return output, err
+}
+
+// CreateGraph calls /creategraph
+func (c *Client) CreateGraph(ctx context.Context, input dtos.CreateGraphInputDTO) (common.BasicAPIResponse, error) {
+	output := common.BasicAPIResponse{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/creategraph", input: &input, output: &output})
+	return output, err
+}
+
+// GetDetailsForGames calls /getdetailsforgames
+func (c *Client) GetDetailsForGames(ctx context.Context, input dtos.GetDetailsForGamesInputDTO) (dtos.GetDetailsForGamesDTO, error) {
+	output := dtos.GetDetailsForGamesDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getdetailsforgames", input: &input, output: &output, retry: true})
+	return output, err
+}
+
+// HasBeenCrawledBefore calls /hasbeencrawledbefore
+func (c *Client) HasBeenCrawledBefore(ctx context.Context, input dtos.HasBeenCrawledBeforeInputDTO) (common.BasicAPIResponse, error) {
+	output := common.BasicAPIResponse{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/hasbeencrawledbefore", input: &input, output: &output, retry: true})
+	return output, err
+}
+
+// DoesProcessedGraphDataExist calls /doesprocessedgraphdataexist/{crawlid}
+func (c *Client) DoesProcessedGraphDataExist(ctx context.Context, crawlID string) (dtos.DoesProcessedGraphDataExistDTO, error) {
+	output := dtos.DoesProcessedGraphDataExistDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/doesprocessedgraphdataexist/" + url.PathEscape(crawlID), output: &output, retry: true})
+	return output, err
+}
+
+// GetProcessedGraphData calls /getprocessedgraphdata/{crawlid}
+func (c *Client) GetProcessedGraphData(ctx context.Context, crawlID string) (dtos.GetProcessedGraphDataDTO, error) {
+	output := dtos.GetProcessedGraphDataDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getprocessedgraphdata/" + url.PathEscape(crawlID), output: &output, retry: true})
+	return output, err
+}
+
+// GetShortestPaths calls /getshortestpaths
+func (c *Client) GetShortestPaths(ctx context.Context, input dtos.GetShortestPathsInputDTO) (dtos.GetShortestPathsDTO, error) {
+	output := dtos.GetShortestPathsDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getshortestpaths", input: &input, output: &output, retry: true})
+	return output, err
+}
+
+// GetSharedGames calls /getsharedgames
+func (c *Client) GetSharedGames(ctx context.Context, input dtos.GetSharedGamesInputDTO) (dtos.GetSharedGamesDTO, error) {
+	output := dtos.GetSharedGamesDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getsharedgames", input: &input, output: &output, retry: true})
+	return output, err
+}
+
+// GetMostSimilarFriends calls /getmostsimilarfriends/{crawlid}/{steamid}
+func (c *Client) GetMostSimilarFriends(ctx context.Context, crawlID, steamID string) (dtos.GetMostSimilarFriendsDTO, error) {
+	output := dtos.GetMostSimilarFriendsDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getmostsimilarfriends/" + url.PathEscape(crawlID) + "/" + url.PathEscape(steamID), output: &output, retry: true})
+	return output, err
+}
+
+// GetRecommendations calls /getrecommendations/{crawlid}/{steamid}
+func (c *Client) GetRecommendations(ctx context.Context, crawlID, steamID string) (dtos.GetRecommendationsDTO, error) {
+	output := dtos.GetRecommendationsDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getrecommendations/" + url.PathEscape(crawlID) + "/" + url.PathEscape(steamID), output: &output, retry: true})
+	return output, err
+}
+
+// GetNetworkStats calls /getnetworkstats/{crawlid}
+func (c *Client) GetNetworkStats(ctx context.Context, crawlID string) (dtos.GetNetworkStatsDTO, error) {
+	output := dtos.GetNetworkStatsDTO{}
+	err := c.do(ctx, call{method: http.MethodGet, path: "/getnetworkstats/" + url.PathEscape(crawlID), output: &output, retry: true})
+	return output, err
+}
+
+// GetCrawlDiff calls /getcrawldiff
+func (c *Client) GetCrawlDiff(ctx context.Context, input dtos.GetCrawlDiffInputDTO) (dtos.GetCrawlDiffDTO, error) {
+	output := dtos.GetCrawlDiffDTO{}
+	err := c.do(ctx, call{method: http.MethodPost, path: "/getcrawldiff", input: &input, output: &output, retry: true})
+	return output, err
+}
@@ -0,0 +1,24 @@
+// Command openapigen writes the OpenAPI document of the services' endpoints,
+// it is run by go generate ./openapi
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/neosteamfriendgraphing/common/openapi"
+)
+
+func main() {
+	output := flag.String("o", "openapi.json", "file to write the OpenAPI document to")
+	flag.Parse()
+
+	spec, err := openapi.Spec()
+	if err != nil {
+		log.Fatalf("could not generate OpenAPI document: %v", err)
+	}
+	if err := os.WriteFile(*output, spec, 0644); err != nil {
+		log.Fatalf("could not write OpenAPI document: %v", err)
+	}
+}
@@ -0,0 +1,460 @@
+package compact
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+	"github.com/neosteamfriendgraphing/common/graph"
+)
+
+// formatVersion is increased whenever the layout of the encoding changes
+const formatVersion = 1
+
+// magic starts every encoded crawl so it can't be mistaken for JSON,
+// processedGraphDataMagic starts every encoded response instead
+var (
+	magic                   = []byte("SFG")
+	processedGraphDataMagic = []byte("SFP")
+)
+
+// ErrInvalidData is returned when decoding data that was not
+// produced by Marshal or has been truncated
+var ErrInvalidData = errors.New("invalid compact data")
+
+// Marshal encodes a crawl in a binary form that is much smaller than JSON
+// for large crawls. Every string is stored once in a table and referred to
+// by index, and integers are stored as varints
+func Marshal(data common.UsersGraphData) ([]byte, error) {
+	e := newEncoder()
+	e.usersGraphData(data)
+	return e.bytes(magic), nil
+}
+
+// Unmarshal decodes a crawl encoded by Marshal into data
+func Unmarshal(b []byte, data *common.UsersGraphData) error {
+	d, err := newDecoder(b, magic)
+	if err != nil {
+		return err
+	}
+	decoded := d.usersGraphData()
+	if err := d.finish(); err != nil {
+		return err
+	}
+	*data = decoded
+	return nil
+}
+
+// MarshalProcessedGraphData encodes a whole /getprocessedgraphdata
+// response, the crawl in the same form as Marshal along with its
+// status, layout and prune report
+func MarshalProcessedGraphData(response dtos.GetProcessedGraphDataDTO) ([]byte, error) {
+	e := newEncoder()
+	e.string(response.Status)
+	e.usersGraphData(response.UserGraphData)
+
+	steamIDs := make([]string, 0, len(response.Layout))
+	for steamID := range response.Layout {
+		steamIDs = append(steamIDs, steamID)
+	}
+	sort.Strings(steamIDs)
+	e.length(len(steamIDs), response.Layout == nil)
+	for _, steamID := range steamIDs {
+		e.string(steamID)
+		e.float(response.Layout[steamID].X)
+		e.float(response.Layout[steamID].Y)
+	}
+
+	if response.Pruned == nil {
+		e.body.WriteByte(0)
+	} else {
+		e.body.WriteByte(1)
+		e.string(string(response.Pruned.Strategy))
+		e.int(int64(response.Pruned.MaxNodes))
+		e.int(int64(response.Pruned.KeptNodes))
+		e.length(len(response.Pruned.DroppedNodes), response.Pruned.DroppedNodes == nil)
+		for _, steamID := range response.Pruned.DroppedNodes {
+			e.string(steamID)
+		}
+		e.int(int64(response.Pruned.DroppedEdges))
+	}
+	return e.bytes(processedGraphDataMagic), nil
+}
+
+// UnmarshalProcessedGraphData decodes a response encoded
+// by MarshalProcessedGraphData into response
+func UnmarshalProcessedGraphData(b []byte, response *dtos.GetProcessedGraphDataDTO) error {
+	d, err := newDecoder(b, processedGraphDataMagic)
+	if err != nil {
+		return err
+	}
+	decoded := dtos.GetProcessedGraphDataDTO{
+		Status:        d.string(),
+		UserGraphData: d.usersGraphData(),
+	}
+	if count, isNil := d.length(); !isNil {
+		decoded.Layout = make(map[string]graph.Position, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			decoded.Layout[d.string()] = graph.Position{X: d.float(), Y: d.float()}
+		}
+	}
+	switch hasPruned := d.flag(); hasPruned {
+	case 0:
+	case 1:
+		decoded.Pruned = &graph.PruneReport{
+			Strategy:  graph.PruneStrategy(d.string()),
+			MaxNodes:  int(d.int()),
+			KeptNodes: int(d.int()),
+		}
+		if count, isNil := d.length(); !isNil {
+			decoded.Pruned.DroppedNodes = make([]string, 0, count)
+			for i := 0; i < count && d.err == nil; i++ {
+				decoded.Pruned.DroppedNodes = append(decoded.Pruned.DroppedNodes, d.string())
+			}
+		}
+		decoded.Pruned.DroppedEdges = int(d.int())
+	default:
+		d.fail("bad prune report flag %d", hasPruned)
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	*response = decoded
+	return nil
+}
+
+// encoder writes the body of an encoding while building its string table
+type encoder struct {
+	body      bytes.Buffer
+	strings   map[string]uint64
+	table     []string
+	tableSize int
+	scratch   [binary.MaxVarintLen64]byte
+}
+
+func newEncoder() *encoder {
+	return &encoder{strings: make(map[string]uint64)}
+}
+
+// bytes returns the string table and body after magic and the version
+func (e *encoder) bytes(magic []byte) []byte {
+	out := bytes.NewBuffer(make([]byte, 0, len(magic)+1+e.tableSize+e.body.Len()))
+	out.Write(magic)
+	out.WriteByte(formatVersion)
+	writeUvarint(out, uint64(len(e.table)))
+	for _, s := range e.table {
+		writeUvarint(out, uint64(len(s)))
+		out.WriteString(s)
+	}
+	out.Write(e.body.Bytes())
+	return out.Bytes()
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+}
+
+func (e *encoder) uint(v uint64) {
+	e.body.Write(e.scratch[:binary.PutUvarint(e.scratch[:], v)])
+}
+
+func (e *encoder) int(v int64) {
+	e.body.Write(e.scratch[:binary.PutVarint(e.scratch[:], v)])
+}
+
+func (e *encoder) string(s string) {
+	index, exists := e.strings[s]
+	if !exists {
+		index = uint64(len(e.table))
+		e.strings[s] = index
+		e.table = append(e.table, s)
+		e.tableSize += binary.MaxVarintLen64 + len(s)
+	}
+	e.uint(index)
+}
+
+func (e *encoder) float(v float64) {
+	binary.LittleEndian.PutUint64(e.scratch[:8], math.Float64bits(v))
+	e.body.Write(e.scratch[:8])
+}
+
+// length writes the length of a slice, 0 is reserved for nil so
+// that a nil slice is still null once decoded and encoded as JSON
+func (e *encoder) length(n int, isNil bool) {
+	if isNil {
+		e.uint(0)
+		return
+	}
+	e.uint(uint64(n) + 1)
+}
+
+func (e *encoder) usersGraphData(data common.UsersGraphData) {
+	e.int(int64(data.SchemaVersion))
+	e.graphInformation(data.UserDetails)
+	e.length(len(data.FriendDetails), data.FriendDetails == nil)
+	for _, user := range data.FriendDetails {
+		e.graphInformation(user)
+	}
+	e.length(len(data.TopGameDetails), data.TopGameDetails == nil)
+	for _, game := range data.TopGameDetails {
+		e.int(int64(game.AppID))
+		e.string(game.Name)
+	}
+}
+
+func (e *encoder) graphInformation(user common.UsersGraphInformation) {
+	e.userDocument(user.User)
+	e.string(user.FromID)
+	e.int(int64(user.MaxLevel))
+	e.int(int64(user.CurrentLevel))
+	if user.Centrality == nil {
+		e.body.WriteByte(0)
+		return
+	}
+	e.body.WriteByte(1)
+	for _, score := range []float64{user.Centrality.Degree, user.Centrality.Betweenness, user.Centrality.Closeness, user.Centrality.PageRank} {
+		e.float(score)
+	}
+}
+
+func (e *encoder) userDocument(user common.UserDocument) {
+	e.int(int64(user.SchemaVersion))
+	e.string(user.AccDetails.SteamID)
+	e.string(user.AccDetails.Personaname)
+	e.string(user.AccDetails.Profileurl)
+	e.string(user.AccDetails.Avatar)
+	e.int(int64(user.AccDetails.Timecreated))
+	e.string(user.AccDetails.Loccountrycode)
+	e.length(len(user.FriendIDs), user.FriendIDs == nil)
+	for _, friendID := range user.FriendIDs {
+		e.string(friendID)
+	}
+	e.length(len(user.GamesOwned), user.GamesOwned == nil)
+	for _, game := range user.GamesOwned {
+		e.int(int64(game.AppID))
+		e.int(int64(game.Playtime_Forever))
+		e.int(int64(game.Playtime_2Weeks))
+	}
+	e.int(user.InsertionTime)
+}
+
+// decoder reads an encoding, after the first error every
+// read returns a zero value and err is left unchanged
+type decoder struct {
+	buf   []byte
+	table []string
+	err   error
+}
+
+// newDecoder checks the magic and format version of b and reads its string table
+func newDecoder(b []byte, magic []byte) (*decoder, error) {
+	if !bytes.HasPrefix(b, magic) || len(b) < len(magic)+1 {
+		return nil, ErrInvalidData
+	}
+	version := b[len(magic)]
+	if version != formatVersion {
+		return nil, fmt.Errorf("unsupported compact format version %d", version)
+	}
+
+	d := &decoder{buf: b[len(magic)+1:]}
+	count := d.count()
+	d.table = make([]string, 0, count)
+	for i := 0; i < count && d.err == nil; i++ {
+		size := d.count()
+		if d.err == nil {
+			d.table = append(d.table, string(d.buf[:size]))
+			d.buf = d.buf[size:]
+		}
+	}
+	return d, nil
+}
+
+// finish returns the first error while decoding, or an
+// error if there is data left over
+func (d *decoder) finish() error {
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.buf) != 0 {
+		return fmt.Errorf("%w: %d unexpected trailing bytes", ErrInvalidData, len(d.buf))
+	}
+	return nil
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidData}, args...)...)
+	}
+}
+
+func (d *decoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail("bad varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) int() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail("bad varint")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// count reads a size, which can never be more than the bytes left as
+// every element takes at least one byte. This stops corrupt data
+// from causing huge allocations
+func (d *decoder) count() int {
+	v := d.uint()
+	if v > uint64(len(d.buf)) {
+		d.fail("size %d is larger than the remaining data", v)
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) length() (int, bool) {
+	v := d.uint()
+	if v == 0 || d.err != nil {
+		return 0, true
+	}
+	if v-1 > uint64(len(d.buf)) {
+		d.fail("size %d is larger than the remaining data", v-1)
+		return 0, true
+	}
+	return int(v - 1), false
+}
+
+func (d *decoder) string() string {
+	index := d.uint()
+	if d.err != nil {
+		return ""
+	}
+	if index >= uint64(len(d.table)) {
+		d.fail("string %d is not in the table", index)
+		return ""
+	}
+	return d.table[index]
+}
+
+func (d *decoder) float() float64 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) < 8 {
+		d.fail("truncated float")
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf))
+	d.buf = d.buf[8:]
+	return v
+}
+
+// flag reads a single byte marking whether an optional value is present
+func (d *decoder) flag() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.fail("truncated flag")
+		return 0
+	}
+	flag := d.buf[0]
+	d.buf = d.buf[1:]
+	return flag
+}
+
+func (d *decoder) usersGraphData() common.UsersGraphData {
+	data := common.UsersGraphData{
+		SchemaVersion: int(d.int()),
+		UserDetails:   d.graphInformation(),
+	}
+	if count, isNil := d.length(); !isNil {
+		data.FriendDetails = make([]common.UsersGraphInformation, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			data.FriendDetails = append(data.FriendDetails, d.graphInformation())
+		}
+	}
+	if count, isNil := d.length(); !isNil {
+		data.TopGameDetails = make([]common.BareGameInfo, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			data.TopGameDetails = append(data.TopGameDetails, common.BareGameInfo{
+				AppID: int(d.int()),
+				Name:  d.string(),
+			})
+		}
+	}
+	return data
+}
+
+func (d *decoder) graphInformation() common.UsersGraphInformation {
+	user := common.UsersGraphInformation{
+		User:         d.userDocument(),
+		FromID:       d.string(),
+		MaxLevel:     int(d.int()),
+		CurrentLevel: int(d.int()),
+	}
+	switch hasCentrality := d.flag(); hasCentrality {
+	case 0:
+	case 1:
+		user.Centrality = &common.CentralityScores{
+			Degree:      d.float(),
+			Betweenness: d.float(),
+			Closeness:   d.float(),
+			PageRank:    d.float(),
+		}
+	default:
+		d.fail("bad centrality flag %d", hasCentrality)
+	}
+	return user
+}
+
+func (d *decoder) userDocument() common.UserDocument {
+	user := common.UserDocument{
+		SchemaVersion: int(d.int()),
+		AccDetails: common.AccDetailsDocument{
+			SteamID:        d.string(),
+			Personaname:    d.string(),
+			Profileurl:     d.string(),
+			Avatar:         d.string(),
+			Timecreated:    int(d.int()),
+			Loccountrycode: d.string(),
+		},
+	}
+	if count, isNil := d.length(); !isNil {
+		user.FriendIDs = make([]string, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			user.FriendIDs = append(user.FriendIDs, d.string())
+		}
+	}
+	if count, isNil := d.length(); !isNil {
+		user.GamesOwned = make([]common.GameOwnedDocument, 0, count)
+		for i := 0; i < count && d.err == nil; i++ {
+			user.GamesOwned = append(user.GamesOwned, common.GameOwnedDocument{
+				AppID:            int(d.int()),
+				Playtime_Forever: int(d.int()),
+				Playtime_2Weeks:  int(d.int()),
+			})
+		}
+	}
+	user.InsertionTime = d.int()
+	return user
+}
@@ -0,0 +1,161 @@
+package compact
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCrawl returns a crawl of users who each have friends and
+// games drawn from a shared pool, as in a real crawl
+func newTestCrawl(users int) common.UsersGraphData {
+	rng := rand.New(rand.NewSource(1))
+	newUser := func(i, level int) common.UsersGraphInformation {
+		user := common.UsersGraphInformation{
+			User: common.UserDocument{
+				AccDetails: common.AccDetailsDocument{
+					SteamID:        fmt.Sprintf("7656119%010d", i),
+					Personaname:    fmt.Sprintf("user%d", i),
+					Profileurl:     fmt.Sprintf("https://steamcommunity.com/profiles/7656119%010d/", i),
+					Avatar:         fmt.Sprintf("https://avatars.akamai.steamstatic.com/%d.jpg", i),
+					Timecreated:    1200000000 + rng.Intn(400000000),
+					Loccountrycode: []string{"IE", "GB", "US", ""}[rng.Intn(4)],
+				},
+				FriendIDs:     []string{},
+				GamesOwned:    []common.GameOwnedDocument{},
+				InsertionTime: 1640000000000 + int64(i),
+			},
+			FromID:       fmt.Sprintf("7656119%010d", rng.Intn(users)),
+			MaxLevel:     3,
+			CurrentLevel: level,
+		}
+		for f := 0; f < 20; f++ {
+			user.User.FriendIDs = append(user.User.FriendIDs, fmt.Sprintf("7656119%010d", rng.Intn(users)))
+		}
+		for g := 0; g < 30; g++ {
+			user.User.GamesOwned = append(user.User.GamesOwned, common.GameOwnedDocument{
+				AppID:            rng.Intn(2000) * 10,
+				Playtime_Forever: rng.Intn(100000),
+				Playtime_2Weeks:  rng.Intn(3) * rng.Intn(1000),
+			})
+		}
+		return user
+	}
+
+	data := common.UsersGraphData{
+		SchemaVersion:  common.CurrentSchemaVersion,
+		UserDetails:    newUser(0, 0),
+		FriendDetails:  []common.UsersGraphInformation{},
+		TopGameDetails: []common.BareGameInfo{{AppID: 10, Name: "Counter-Strike"}, {AppID: 570, Name: "Dota 2"}},
+	}
+	for i := 1; i < users; i++ {
+		data.FriendDetails = append(data.FriendDetails, newUser(i, 1+i%3))
+	}
+	data.FriendDetails[0].Centrality = &common.CentralityScores{Degree: 0.5, Betweenness: 0.25, Closeness: 1.0 / 3, PageRank: 0.01}
+	return data
+}
+
+func assertRoundTripsToSameJSON(t *testing.T, data common.UsersGraphData) {
+	encoded, err := Marshal(data)
+	assert.Nil(t, err)
+
+	decoded := common.UsersGraphData{}
+	assert.Nil(t, Unmarshal(encoded, &decoded))
+
+	expectedJSON, err := json.Marshal(data)
+	assert.Nil(t, err)
+	actualJSON, err := json.Marshal(decoded)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expectedJSON), string(actualJSON))
+	assert.Equal(t, data, decoded)
+}
+
+func TestRoundTripMatchesJSON(t *testing.T) {
+	assertRoundTripsToSameJSON(t, newTestCrawl(200))
+}
+
+func TestRoundTripKeepsNilSlicesAndEmptyValues(t *testing.T) {
+	assertRoundTripsToSameJSON(t, common.UsersGraphData{})
+	assertRoundTripsToSameJSON(t, common.UsersGraphData{
+		UserDetails: common.UsersGraphInformation{
+			User:       common.UserDocument{FriendIDs: []string{}},
+			MaxLevel:   -1,
+			Centrality: &common.CentralityScores{},
+		},
+		FriendDetails: []common.UsersGraphInformation{},
+	})
+}
+
+func TestRoundTripOfJSONDecodedCrawl(t *testing.T) {
+	input := `{"userdetails":{"User":{"accdetails":{"steamid":"76561197960287930","personaname":"Rabscuttle"},"friendids":["76561197960265731"],"gamesowned":[{"appid":10,"playtime_forever":32}],"insertiontime":0},"FromID":"","MaxLevel":2,"CurrentLevel":0},"frienddetails":null,"topgamedetails":[]}`
+	data := common.UsersGraphData{}
+	assert.Nil(t, json.Unmarshal([]byte(input), &data))
+
+	assertRoundTripsToSameJSON(t, data)
+}
+
+func TestRoundTripKeepsUserSchemaVersion(t *testing.T) {
+	data := newTestCrawl(5)
+	data.UserDetails.User.SchemaVersion = common.CurrentSchemaVersion
+	encoded, err := Marshal(data)
+	assert.Nil(t, err)
+
+	decoded := common.UsersGraphData{}
+	assert.Nil(t, Unmarshal(encoded, &decoded))
+	assert.Equal(t, data, decoded)
+}
+
+func TestMarshalIsSmallerThanJSON(t *testing.T) {
+	data := newTestCrawl(500)
+	jsonEncoded, err := json.Marshal(data)
+	assert.Nil(t, err)
+
+	encoded, err := Marshal(data)
+
+	assert.Nil(t, err)
+	assert.Less(t, len(encoded)*3, len(jsonEncoded))
+}
+
+func TestUnmarshalRejectsTruncatedData(t *testing.T) {
+	encoded, err := Marshal(newTestCrawl(5))
+	assert.Nil(t, err)
+
+	for i := 0; i < len(encoded); i++ {
+		data := common.UsersGraphData{}
+		assert.Error(t, Unmarshal(encoded[:i], &data), "length %d", i)
+	}
+}
+
+func TestUnmarshalRejectsInvalidData(t *testing.T) {
+	encoded, err := Marshal(newTestCrawl(5))
+	assert.Nil(t, err)
+	data := common.UsersGraphData{}
+
+	assert.ErrorIs(t, Unmarshal([]byte(`{"userdetails":{}}`), &data), ErrInvalidData)
+	assert.ErrorIs(t, Unmarshal(append(encoded, 0), &data), ErrInvalidData)
+
+	wrongVersion := append([]byte{}, encoded...)
+	wrongVersion[len(magic)] = formatVersion + 1
+	assert.EqualError(t, Unmarshal(wrongVersion, &data), fmt.Sprintf("unsupported compact format version %d", formatVersion+1))
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	data := newTestCrawl(5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Marshal(data)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	encoded, _ := Marshal(newTestCrawl(5000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data := common.UsersGraphData{}
+		Unmarshal(encoded, &data)
+	}
+}
@@ -0,0 +1,86 @@
+package compact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/neosteamfriendgraphing/common/dtos"
+)
+
+// ContentType is the media type of compact encoded crawls
+const ContentType = "application/vnd.neosteamfriendgraphing.compact"
+
+// Accepts returns whether a request lists ContentType in its Accept
+// header, so services only send compact data to clients that opt in
+func Accepts(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil || mediaType != ContentType {
+				continue
+			}
+			if q, exists := params["q"]; exists {
+				if quality, err := strconv.ParseFloat(q, 64); err != nil || quality == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// RequestCompact sets the Accept header of an outgoing request so that
+// a crawl is returned compact encoded when the service supports it
+func RequestCompact(req *http.Request) {
+	req.Header.Set("Accept", ContentType+", application/json;q=0.9")
+}
+
+// WriteResponse writes a response compact encoded with
+// MarshalProcessedGraphData if the request accepts it, otherwise as JSON
+func WriteResponse(w http.ResponseWriter, r *http.Request, statusCode int, response dtos.GetProcessedGraphDataDTO) error {
+	w.Header().Add("Vary", "Accept")
+	if !Accepts(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		return json.NewEncoder(w).Encode(response)
+	}
+
+	encoded, err := MarshalProcessedGraphData(response)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	w.WriteHeader(statusCode)
+	_, err = w.Write(encoded)
+	return err
+}
+
+// ReadResponse reads a response written by WriteResponse
+// in whichever encoding the service chose
+func ReadResponse(res *http.Response) (dtos.GetProcessedGraphDataDTO, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return dtos.GetProcessedGraphDataDTO{}, fmt.Errorf("could not read response: %w", err)
+	}
+
+	response := dtos.GetProcessedGraphDataDTO{}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == ContentType {
+		if err := UnmarshalProcessedGraphData(body, &response); err != nil {
+			return dtos.GetProcessedGraphDataDTO{}, err
+		}
+		return response, nil
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return dtos.GetProcessedGraphDataDTO{}, fmt.Errorf("could not parse response: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,92 @@
+package compact
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+	"github.com/neosteamfriendgraphing/common/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccepts(t *testing.T) {
+	tests := map[string]bool{
+		"":                                     false,
+		"application/json":                     false,
+		"*/*":                                  false,
+		ContentType:                            true,
+		"application/json, " + ContentType:     true,
+		ContentType + ";q=0.5, */*;q=0.1":      true,
+		ContentType + ";q=0, application/json": false,
+	}
+	for accept, expected := range tests {
+		req := httptest.NewRequest("GET", "/getprocessedgraphdata/abc", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		assert.Equal(t, expected, Accepts(req), accept)
+	}
+}
+
+func newTestServer(t *testing.T, response dtos.GetProcessedGraphDataDTO) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, WriteResponse(w, r, http.StatusOK, response))
+	}))
+}
+
+func TestWriteAndReadResponse(t *testing.T) {
+	response := dtos.GetProcessedGraphDataDTO{
+		Status:        "success",
+		UserGraphData: newTestCrawl(20),
+		Layout: map[string]graph.Position{
+			"76561197960287930": {X: 0, Y: 0},
+			"76561197960287931": {X: -12.5, Y: 40.25},
+		},
+		Pruned: &graph.PruneReport{
+			Strategy:     graph.PruneKCore,
+			MaxNodes:     20,
+			KeptNodes:    20,
+			DroppedNodes: []string{"76561197960287999"},
+			DroppedEdges: 3,
+		},
+	}
+	server := newTestServer(t, response)
+	defer server.Close()
+
+	for _, compact := range []bool{true, false} {
+		req, err := http.NewRequest("GET", server.URL, nil)
+		assert.Nil(t, err)
+		if compact {
+			RequestCompact(req)
+		}
+		res, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+
+		read, err := ReadResponse(res)
+		res.Body.Close()
+
+		assert.Nil(t, err)
+		assert.Equal(t, response, read)
+		assert.Equal(t, "Accept", res.Header.Get("Vary"))
+		if compact {
+			assert.Equal(t, ContentType, res.Header.Get("Content-Type"))
+		} else {
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestProcessedGraphDataRoundTripKeepsNilValues(t *testing.T) {
+	response := dtos.GetProcessedGraphDataDTO{UserGraphData: newTestCrawl(3)}
+	encoded, err := MarshalProcessedGraphData(response)
+	assert.Nil(t, err)
+
+	decoded := dtos.GetProcessedGraphDataDTO{}
+	assert.Nil(t, UnmarshalProcessedGraphData(encoded, &decoded))
+	assert.Equal(t, response, decoded)
+
+	assert.ErrorIs(t, Unmarshal(encoded, &common.UsersGraphData{}), ErrInvalidData)
+	assert.ErrorIs(t, UnmarshalProcessedGraphData(encoded[:len(encoded)-1], &decoded), ErrInvalidData)
+}
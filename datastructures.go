@@ -1,7 +1,24 @@
 package common
 
-// UserDocument is the schema for information stored for a given user
+// Schema versions of documents that are persisted or sent between services.
+// Documents saved before versioning was added have no version and are
+// treated as SchemaVersion1, see the schema package for decoding and
+// migrating documents between versions
+const (
+	SchemaVersion1 = 1
+	// SchemaVersion2 gives UsersGraphInformation and UserDetails lowercase
+	// JSON keys and fixes the spelling of originalcrawltarget in
+	// dtos.SaveUserDTO
+	SchemaVersion2 = 2
+
+	CurrentSchemaVersion = SchemaVersion2
+)
+
+// UserDocument is the schema for information stored for a given user.
+// SchemaVersion is only set when the document is stored on its own,
+// nested documents take the version of the document they are in
 type UserDocument struct {
+	SchemaVersion int                 `json:"schemaversion,omitempty"`
 	AccDetails    AccDetailsDocument  `json:"accdetails"`
 	FriendIDs     []string            `json:"friendids"`
 	GamesOwned    []GameOwnedDocument `json:"gamesowned"`
@@ -24,14 +41,16 @@ type AccDetailsDocument struct {
 type GameOwnedDocument struct {
 	AppID            int `json:"appid"`
 	Playtime_Forever int `json:"playtime_forever"`
+	Playtime_2Weeks  int `json:"playtime_2weeks,omitempty"`
 }
 
 // GameInfo is the schema for information stored for each steam game
 type GameInfoDocument struct {
-	AppID      int    `json:"appid"`
-	Name       string `json:"name"`
-	ImgIconURL string `json:"imgiconurl"`
-	ImgLogoURL string `json:"imglogourl"`
+	SchemaVersion int    `json:"schemaversion,omitempty"`
+	AppID         int    `json:"appid"`
+	Name          string `json:"name"`
+	ImgIconURL    string `json:"imgiconurl"`
+	ImgLogoURL    string `json:"imglogourl"`
 }
 
 // CrawlingStatus stores the total number of friends to crawl
@@ -40,6 +59,7 @@ type GameInfoDocument struct {
 // user has been crawled completely and processing of their
 // data should start
 type CrawlingStatus struct {
+	SchemaVersion       int    `json:"schemaversion,omitempty"`
 	TimeStarted         int64  `json:"timestarted"`
 	CrawlID             string `json:"crawlid"`
 	OriginalCrawlTarget string `json:"originalcrawltarget"`
@@ -53,23 +73,51 @@ type CrawlingStatus struct {
 // and their friend network's details along with the ten most popular
 // games of the network are saved here
 type UsersGraphData struct {
+	SchemaVersion int                     `json:"schemaversion,omitempty"`
 	UserDetails   UsersGraphInformation   `json:"userdetails"`
 	FriendDetails []UsersGraphInformation `json:"frienddetails"`
 	// TopGameDetails is the details of the most played games
-	// for a given user. Ten are initially chosen but only the games
-	// with supplied details from the steam web API are included
-	// in the final output which can lead to there being less than
-	// ten games. At most only ten games will be present
+	// for a given user. Only games with supplied details from the
+	// steam web API are included, games without details are replaced
+	// by the next most played so there are ten unless the network
+	// owns fewer than ten games with details, see games.TopGames
+	// for how they are chosen
 	TopGameDetails []BareGameInfo `json:"topgamedetails"`
 }
 
+// DistinctUsers returns each distinct user in a crawl, the crawl target
+// first, skipping friends found more than once
+func (data UsersGraphData) DistinctUsers() []UserDocument {
+	seen := make(map[string]bool)
+	users := []UserDocument{}
+	for _, user := range append([]UsersGraphInformation{data.UserDetails}, data.FriendDetails...) {
+		if steamID := user.User.AccDetails.SteamID; !seen[steamID] {
+			seen[steamID] = true
+			users = append(users, user.User)
+		}
+	}
+	return users
+}
+
 // UsersGraphInformation stores information for each user in relation
 // to which user they are connected with initially in the network
 type UsersGraphInformation struct {
-	User         UserDocument
-	FromID       string
-	MaxLevel     int
-	CurrentLevel int
+	User         UserDocument `json:"user"`
+	FromID       string       `json:"fromid"`
+	MaxLevel     int          `json:"maxlevel"`
+	CurrentLevel int          `json:"currentlevel"`
+	// Centrality is only set when centrality metrics
+	// have been computed for the network
+	Centrality *CentralityScores `json:"centrality,omitempty"`
+}
+
+// CentralityScores measure how central a user is within their friend
+// network, used to highlight the "hub" users in a crawl
+type CentralityScores struct {
+	Degree      float64 `json:"degree"`
+	Betweenness float64 `json:"betweenness"`
+	Closeness   float64 `json:"closeness"`
+	PageRank    float64 `json:"pagerank"`
 }
 
 // BareGameInfo correlates a steam appID to a game title
@@ -85,4 +133,6 @@ type LoggingFields struct {
 	LogPaths []string
 	NodeIPV4 string
 	Service  string
+	Version  string
+	Commit   string
 }
@@ -1,14 +1,19 @@
 package dtos
 
 import (
+	"encoding/json"
+
 	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/games"
+	"github.com/neosteamfriendgraphing/common/graph"
 )
 
 // SaveUserDTO is the input schema for saving users to the database. It takes
 // the original crawl target user (that initally caused this crawl) and the
 // current user to be saved
 type SaveUserDTO struct {
-	OriginalCrawlTarget string                    `json:"orginalcrawltarget"`
+	SchemaVersion       int                       `json:"schemaversion,omitempty"`
+	OriginalCrawlTarget string                    `json:"originalcrawltarget"`
 	CrawlID             string                    `json:"crawlid"`
 	CurrentLevel        int                       `json:"currentlevel"`
 	MaxLevel            int                       `json:"maxlevel"`
@@ -16,6 +21,46 @@ type SaveUserDTO struct {
 	GamesOwnedFull      []common.GameInfoDocument `json:"gamesownedfull"`
 }
 
+// saveUserDTOJSON is how a SaveUserDTO is decoded, without
+// its UnmarshalJSON and with the misspelt legacy key
+type saveUserDTOJSON struct {
+	saveUserDTO
+	LegacyOriginalCrawlTarget string `json:"orginalcrawltarget"`
+}
+
+type saveUserDTO SaveUserDTO
+
+func (decoded saveUserDTOJSON) dto() SaveUserDTO {
+	dto := SaveUserDTO(decoded.saveUserDTO)
+	if dto.OriginalCrawlTarget == "" {
+		dto.OriginalCrawlTarget = decoded.LegacyOriginalCrawlTarget
+	}
+	return dto
+}
+
+// UnmarshalJSON also accepts the misspelt orginalcrawltarget sent by
+// services still using SchemaVersion1. Unknown fields are ignored so
+// documents written by newer services can still be read
+func (dto *SaveUserDTO) UnmarshalJSON(b []byte) error {
+	decoded := saveUserDTOJSON{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*dto = decoded.dto()
+	return nil
+}
+
+// DecodeStrict decodes like UnmarshalJSON but rejects unknown
+// fields, it's used by util.DecodeAndValidate
+func (dto *SaveUserDTO) DecodeStrict(decoder *json.Decoder) error {
+	decoded := saveUserDTOJSON{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+	*dto = decoded.dto()
+	return nil
+}
+
 // GetUserDTO is the returned data when user has been successfully
 // found in the database
 type GetUserDTO struct {
@@ -28,17 +73,18 @@ type GetUserDTO struct {
 // in the database an no steam API calls are needed before updating
 // the crawling status
 type SaveCrawlingStatsDTO struct {
+	SchemaVersion  int                   `json:"schemaversion,omitempty"`
 	CurrentLevel   int                   `json:"currentlevel"`
 	CrawlingStatus common.CrawlingStatus `json:"crawlingstatus"`
 }
 
-// GetCrawlingStatusDTO is returned from GET /getcrawlingstatus
+// GetCrawlingStatusDTO is returned from GET /getcrawlingstatus/{crawlid}
 type GetCrawlingStatusDTO struct {
 	Status         string                `json:"status"`
 	CrawlingStatus common.CrawlingStatus `json:"crawlingstatus"`
 }
 
-// GetGraphableDataForUserDTO is returned from GET /getgraphabledata
+// GetGraphableDataForUserDTO is returned from GET /getgraphabledata/{steamid}
 type GetGraphableDataForUserDTO struct {
 	Username  string   `json:"username"`
 	SteamID   string   `json:"steamid"`
@@ -96,16 +142,92 @@ type HasBeenCrawledBeforeInputDTO struct {
 	SteamID string `json:"steamid"`
 }
 
-// DoesProcessedGraphDataExistDTO is the input format when accessing
-// POST /doesprocessedgraphdataexist
+// DoesProcessedGraphDataExistDTO is the format of returned data from
+// POST /doesprocessedgraphdataexist/{crawlid}. Exists is "true" or "false",
+// ProcessedGraphDataExistsDTO replaces it in a Response
 type DoesProcessedGraphDataExistDTO struct {
 	Status string `json:"status"`
 	Exists string `json:"exists"`
 }
 
 // GetProcessedGraphDataDTO is the format of returned data from
-// POST /getprocessedgraphdata
+// POST /getprocessedgraphdata/{crawlid}. Layout is only included when positions
+// have been computed server side and is keyed by steamID. Pruned is
+// only included when users were dropped to fit the graph view
 type GetProcessedGraphDataDTO struct {
-	Status        string                `json:"status"`
-	UserGraphData common.UsersGraphData `json:"usergraphdata"`
+	Status        string                    `json:"status"`
+	UserGraphData common.UsersGraphData     `json:"usergraphdata"`
+	Layout        map[string]graph.Position `json:"layout,omitempty"`
+	Pruned        *graph.PruneReport        `json:"pruned,omitempty"`
+}
+
+// GetShortestPathsInputDTO is the input format when accessing
+// POST /getshortestpaths
+type GetShortestPathsInputDTO struct {
+	CrawlID       string `json:"crawlid"`
+	FirstSteamID  string `json:"firstSteamID"`
+	SecondSteamID string `json:"secondSteamID"`
+}
+
+// GetShortestPathsDTO is the format of returned data from
+// POST /getshortestpaths, describing how two users are connected
+type GetShortestPathsDTO struct {
+	Status string           `json:"status"`
+	Paths  graph.PathResult `json:"paths"`
+}
+
+// GetSharedGamesInputDTO is the input format when accessing
+// POST /getsharedgames
+type GetSharedGamesInputDTO struct {
+	FirstSteamID  string `json:"firstSteamID"`
+	SecondSteamID string `json:"secondSteamID"`
+}
+
+// GetSharedGamesDTO is the format of returned data from
+// POST /getsharedgames
+type GetSharedGamesDTO struct {
+	Status      string             `json:"status"`
+	SharedGames []games.SharedGame `json:"sharedgames"`
+	Similarity  games.Similarity   `json:"similarity"`
+}
+
+// GetMostSimilarFriendsDTO is the format of returned data from
+// GET /getmostsimilarfriends/{crawlid}/{steamid}, friends are ordered most similar first
+type GetMostSimilarFriendsDTO struct {
+	Status  string             `json:"status"`
+	SteamID string             `json:"steamid"`
+	Friends []games.Similarity `json:"friends"`
+}
+
+// GetRecommendationsDTO is the format of returned data from
+// GET /getrecommendations/{crawlid}/{steamid}, the best recommendation is first
+type GetRecommendationsDTO struct {
+	Status          string                 `json:"status"`
+	SteamID         string                 `json:"steamid"`
+	Recommendations []games.Recommendation `json:"recommendations"`
+}
+
+// GetNetworkStatsDTO is the format of returned data from
+// GET /getnetworkstats/{crawlid}, summarising a crawl's friend network
+type GetNetworkStatsDTO struct {
+	Status  string             `json:"status"`
+	CrawlID string             `json:"crawlid"`
+	Stats   graph.NetworkStats `json:"stats"`
+}
+
+// GetCrawlDiffInputDTO is the input format when accessing
+// POST /getcrawldiff
+type GetCrawlDiffInputDTO struct {
+	OldCrawlID string `json:"oldcrawlid"`
+	NewCrawlID string `json:"newcrawlid"`
+}
+
+// GetCrawlDiffDTO is the format of returned data from
+// POST /getcrawldiff, describing how a user's network
+// changed between two crawls
+type GetCrawlDiffDTO struct {
+	Status     string          `json:"status"`
+	OldCrawlID string          `json:"oldcrawlid"`
+	NewCrawlID string          `json:"newcrawlid"`
+	Changes    graph.ChangeSet `json:"changes"`
 }
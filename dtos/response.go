@@ -0,0 +1,235 @@
+package dtos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/neosteamfriendgraphing/common/util"
+)
+
+// ResponseStatus is the outcome of a request
+type ResponseStatus string
+
+const (
+	// StatusSuccess is returned when the request was handled
+	StatusSuccess ResponseStatus = "success"
+	// StatusInvalid is returned when the request was rejected
+	// because of the caller's input
+	StatusInvalid ResponseStatus = "invalid"
+	// StatusError is returned when the service failed to
+	// handle an otherwise valid request
+	StatusError ResponseStatus = "error"
+)
+
+// Valid returns whether s is one of the known statuses
+func (s ResponseStatus) Valid() bool {
+	switch s {
+	case StatusSuccess, StatusInvalid, StatusError:
+		return true
+	}
+	return false
+}
+
+// ResponseError describes why a request failed, Fields is
+// only set when the request had invalid fields
+type ResponseError struct {
+	Message string            `json:"message"`
+	Fields  []util.FieldError `json:"fields,omitempty"`
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// Pagination describes which part of a longer list a response holds,
+// Page starts from 1 and Total is the number of items across every page
+type Pagination struct {
+	Page     int `json:"page"`
+	PageSize int `json:"pagesize"`
+	Total    int `json:"total"`
+}
+
+// HasNextPage returns whether there are items after this page
+func (p Pagination) HasNextPage() bool {
+	return p.Page*p.PageSize < p.Total
+}
+
+// ResponseMeta describes the request a response is for. Timestamp is
+// when the response was sent and DurationMs is how long the request
+// took to handle, both in milliseconds
+type ResponseMeta struct {
+	RequestID  string      `json:"requestid,omitempty"`
+	Timestamp  int64       `json:"timestamp"`
+	DurationMs int64       `json:"durationms"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Response is the standard envelope for the data returned by an endpoint,
+// replacing the Status string repeated in each output DTO. Data is only
+// set on success and Error otherwise
+type Response[T any] struct {
+	Status ResponseStatus `json:"status"`
+	Data   *T             `json:"data,omitempty"`
+	Error  *ResponseError `json:"error,omitempty"`
+	Meta   ResponseMeta   `json:"meta"`
+}
+
+// NewResponse creates a successful response holding data
+func NewResponse[T any](data T) Response[T] {
+	return Response[T]{Status: StatusSuccess, Data: &data}
+}
+
+// NewErrorResponse creates a failed response, status
+// should be either StatusInvalid or StatusError
+func NewErrorResponse[T any](status ResponseStatus, message string, fields ...util.FieldError) Response[T] {
+	return Response[T]{
+		Status: status,
+		Error:  &ResponseError{Message: message, Fields: fields},
+	}
+}
+
+// WriteResponse writes a response as JSON with the given status code. The
+// status is set from the status code if it is empty, and the request ID and
+// duration are set from vars in the same way as util.SendBasicErrorResponse,
+// falling back to the request's util.RequestIDHeader
+func WriteResponse[T any](w http.ResponseWriter, req *http.Request, response Response[T], vars map[string]string, statusCode int) error {
+	if response.Status == "" {
+		switch {
+		case statusCode < 400:
+			response.Status = StatusSuccess
+		case statusCode < 500:
+			response.Status = StatusInvalid
+		default:
+			response.Status = StatusError
+		}
+	}
+	if response.Meta.RequestID == "" {
+		response.Meta.RequestID = vars["requestID"]
+	}
+	if response.Meta.RequestID == "" {
+		response.Meta.RequestID = req.Header.Get(util.RequestIDHeader)
+	}
+	response.Meta.Timestamp = util.GetCurrentTimeInMs()
+	if startTime, err := strconv.ParseInt(vars["requestStartTime"], 10, 64); err == nil && response.Meta.DurationMs == 0 {
+		response.Meta.DurationMs = response.Meta.Timestamp - startTime
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(response)
+}
+
+// Err returns the response's error, or nil if it was successful
+func (r Response[T]) Err() error {
+	if r.Status == StatusSuccess {
+		return nil
+	}
+	if r.Error == nil {
+		return &ResponseError{Message: string(r.Status)}
+	}
+	return r.Error
+}
+
+// DecodeResponse decodes a Response sent with statusCode. Bodies without
+// meta were written before the envelope was added, such as GetUserDTO, in
+// which case the whole body is decoded into Data, so T should be the legacy
+// DTO or a type with the same JSON keys, and the status and error are taken
+// from the legacy status, message and error keys. Legacy bodies that only
+// have an error are StatusInvalid when statusCode is a 4xx and StatusError
+// otherwise
+func DecodeResponse[T any](b []byte, statusCode int) (Response[T], error) {
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return Response[T]{}, fmt.Errorf("could not decode response: %w", err)
+	}
+	if _, isEnvelope := keys["meta"]; !isEnvelope {
+		return decodeLegacyResponse[T](b, statusCode)
+	}
+
+	response := Response[T]{}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return Response[T]{}, fmt.Errorf("could not decode response: %w", err)
+	}
+	if !response.Status.Valid() {
+		return Response[T]{}, fmt.Errorf("response has unknown status %q", response.Status)
+	}
+	return response, nil
+}
+
+func decodeLegacyResponse[T any](b []byte, statusCode int) (Response[T], error) {
+	legacy := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{}
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return Response[T]{}, fmt.Errorf("could not decode legacy response: %w", err)
+	}
+
+	status := ResponseStatus(strings.ToLower(legacy.Status))
+	switch {
+	case status == "" && legacy.Error != "":
+		// Bodies written by util.SendBasicInvalidResponse and
+		// util.SendBasicErrorResponse only have an error, which
+		// only the status code tells apart
+		status = StatusError
+		if statusCode >= 400 && statusCode < 500 {
+			status = StatusInvalid
+		}
+	case status == "":
+		// Some legacy DTOs, such as GetGraphableDataForUserDTO, have no status
+		status = StatusSuccess
+	case !status.Valid():
+		return Response[T]{}, fmt.Errorf("legacy response has unknown status %q", legacy.Status)
+	}
+
+	if status != StatusSuccess {
+		message := legacy.Error
+		if message == "" {
+			message = legacy.Message
+		}
+		return NewErrorResponse[T](status, message), nil
+	}
+	data := new(T)
+	if err := json.Unmarshal(b, data); err != nil {
+		return Response[T]{}, fmt.Errorf("could not decode legacy response: %w", err)
+	}
+	return Response[T]{Status: status, Data: data}, nil
+}
+
+// ProcessedGraphDataExistsDTO is the data returned from
+// POST /doesprocessedgraphdataexist/{crawlid} in a Response
+type ProcessedGraphDataExistsDTO struct {
+	Exists bool `json:"exists"`
+}
+
+// UnmarshalJSON also accepts the "true" and "false" strings
+// sent as DoesProcessedGraphDataExistDTO.Exists
+func (dto *ProcessedGraphDataExistsDTO) UnmarshalJSON(b []byte) error {
+	decoded := struct {
+		Exists json.RawMessage `json:"exists"`
+	}{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	if len(decoded.Exists) == 0 {
+		dto.Exists = false
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Exists, &dto.Exists); err == nil {
+		return nil
+	}
+	legacy := ""
+	if err := json.Unmarshal(decoded.Exists, &legacy); err != nil {
+		return fmt.Errorf("exists must be a bool: %w", err)
+	}
+	exists, err := strconv.ParseBool(legacy)
+	if err != nil {
+		return fmt.Errorf("exists must be a bool: %w", err)
+	}
+	dto.Exists = exists
+	return nil
+}
@@ -0,0 +1,143 @@
+package dtos
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseRoundTrip(t *testing.T) {
+	response := NewResponse([]string{"76561197960287930"})
+	response.Meta.Pagination = &Pagination{Page: 1, PageSize: 1, Total: 2}
+	encoded, err := json.Marshal(response)
+	assert.Nil(t, err)
+
+	decoded, err := DecodeResponse[[]string](encoded, http.StatusOK)
+
+	assert.Nil(t, err)
+	assert.Equal(t, response, decoded)
+	assert.Nil(t, decoded.Err())
+	assert.True(t, decoded.Meta.Pagination.HasNextPage())
+}
+
+func TestErrorResponseRoundTrip(t *testing.T) {
+	response := NewErrorResponse[GetUserDTO](StatusInvalid, "invalid input", util.FieldError{Field: "steamid", Message: "is required"})
+	encoded, err := json.Marshal(response)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(encoded), `"data"`)
+
+	decoded, err := DecodeResponse[GetUserDTO](encoded, http.StatusBadRequest)
+
+	assert.Nil(t, err)
+	assert.Equal(t, StatusInvalid, decoded.Status)
+	responseErr := &ResponseError{}
+	assert.True(t, errors.As(decoded.Err(), &responseErr))
+	assert.Equal(t, "invalid input", responseErr.Message)
+	assert.Equal(t, []util.FieldError{{Field: "steamid", Message: "is required"}}, responseErr.Fields)
+}
+
+func TestDecodeResponseRejectsUnknownStatus(t *testing.T) {
+	_, err := DecodeResponse[GetUserDTO]([]byte(`{"status": "maybe", "meta": {}}`), http.StatusOK)
+	assert.NotNil(t, err)
+
+	_, err = DecodeResponse[GetUserDTO]([]byte(`{"status": "maybe"}`), http.StatusOK)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeLegacyResponses(t *testing.T) {
+	user, err := DecodeResponse[GetUserDTO]([]byte(`{"status": "success", "user": {"accdetails": {"steamid": "76561197960287930"}}}`), http.StatusOK)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusSuccess, user.Status)
+	assert.Equal(t, "76561197960287930", user.Data.User.AccDetails.SteamID)
+
+	graphable, err := DecodeResponse[GetGraphableDataForUserDTO]([]byte(`{"username": "gabe", "friendids": ["1"]}`), http.StatusOK)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusSuccess, graphable.Status)
+	assert.Equal(t, "gabe", graphable.Data.Username)
+
+	basicErr, err := DecodeResponse[common.BasicAPIResponse]([]byte(`{"status": "error", "message": "could not save user"}`), http.StatusOK)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusError, basicErr.Status)
+	assert.EqualError(t, basicErr.Err(), "could not save user")
+
+	invalid, err := DecodeResponse[GetUserDTO]([]byte(`{"error": "invalid steamid"}`), http.StatusBadRequest)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusInvalid, invalid.Status)
+	assert.Nil(t, invalid.Data)
+	assert.EqualError(t, invalid.Err(), "invalid steamid")
+
+	failed, err := DecodeResponse[GetUserDTO]([]byte(`{"error": "could not get user"}`), http.StatusInternalServerError)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusError, failed.Status)
+	assert.EqualError(t, failed.Err(), "could not get user")
+}
+
+func TestProcessedGraphDataExistsDTODecodesLegacyExists(t *testing.T) {
+	tests := map[string]bool{
+		`{"status": "success", "exists": "true"}`:  true,
+		`{"status": "success", "exists": "false"}`: false,
+		`{"status": "success"}`:                    false,
+	}
+	for body, expected := range tests {
+		response, err := DecodeResponse[ProcessedGraphDataExistsDTO]([]byte(body), http.StatusOK)
+		assert.Nil(t, err, body)
+		assert.Equal(t, expected, response.Data.Exists, body)
+	}
+
+	response, err := DecodeResponse[ProcessedGraphDataExistsDTO]([]byte(`{"status": "success", "data": {"exists": true}, "meta": {}}`), http.StatusOK)
+	assert.Nil(t, err)
+	assert.True(t, response.Data.Exists)
+
+	_, err = DecodeResponse[ProcessedGraphDataExistsDTO]([]byte(`{"status": "success", "exists": "yes"}`), http.StatusOK)
+	assert.NotNil(t, err)
+}
+
+func TestWriteResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/getuser/76561197960287930", nil)
+	startTime := util.GetCurrentTimeInMs() - 250
+	vars := map[string]string{
+		"requestID":        "request-1",
+		"requestStartTime": strconv.FormatInt(startTime, 10),
+	}
+
+	err := WriteResponse(w, req, NewResponse(GetUserDTO{}), vars, http.StatusOK)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	decoded, err := DecodeResponse[GetUserDTO](w.Body.Bytes(), w.Code)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusSuccess, decoded.Status)
+	assert.Equal(t, "request-1", decoded.Meta.RequestID)
+	assert.GreaterOrEqual(t, decoded.Meta.DurationMs, int64(250))
+	assert.GreaterOrEqual(t, decoded.Meta.Timestamp, startTime)
+}
+
+func TestWriteResponseSetsStatusFromStatusCode(t *testing.T) {
+	tests := map[int]ResponseStatus{
+		http.StatusOK:                  StatusSuccess,
+		http.StatusNotFound:            StatusInvalid,
+		http.StatusInternalServerError: StatusError,
+	}
+	for statusCode, expected := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Header.Set(util.RequestIDHeader, "request-2")
+
+		err := WriteResponse(w, req, Response[interface{}]{}, nil, statusCode)
+
+		assert.Nil(t, err)
+		decoded, err := DecodeResponse[interface{}](w.Body.Bytes(), w.Code)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, decoded.Status)
+		assert.Equal(t, "request-2", decoded.Meta.RequestID)
+	}
+}
@@ -0,0 +1,171 @@
+package dtos
+
+import (
+	"github.com/neosteamfriendgraphing/common/util"
+)
+
+const (
+	// MinCrawlLevel and MaxCrawlLevel bound how many levels of
+	// friends of friends a crawl can go
+	MinCrawlLevel = 1
+	MaxCrawlLevel = 3
+	// MaxSteamIDsPerRequest is the most steamIDs that can be looked up
+	// at once, the same limit as the steam web API's GetPlayerSummaries
+	MaxSteamIDsPerRequest = 100
+	// MaxGameIDsPerRequest is the most games that can be looked up at once
+	MaxGameIDsPerRequest = 1000
+	// MaxCrawlIDLength is the longest crawlID accepted
+	MaxCrawlIDLength = 64
+)
+
+func validateSteamID(errs *util.ValidationErrors, field, steamID string) {
+	if steamID == "" {
+		errs.Add(field, "is required")
+	} else if !util.IsValidFormatSteamID(steamID) {
+		errs.Add(field, "must be a 17 digit steamID")
+	}
+}
+
+func validateCrawlID(errs *util.ValidationErrors, field, crawlID string) {
+	if crawlID == "" {
+		errs.Add(field, "is required")
+	} else if len(crawlID) > MaxCrawlIDLength {
+		errs.Add(field, "must be at most %d characters", MaxCrawlIDLength)
+	}
+}
+
+func validateLevel(errs *util.ValidationErrors, field string, level, min, max int) {
+	if level < min || level > max {
+		errs.Add(field, "must be between %d and %d", min, max)
+	}
+}
+
+// dedupe returns values without repeats keeping the first of each,
+// values is copied so the caller's slice is left unchanged
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// Validate checks both users and the levels of the crawl
+func (dto *SaveUserDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateSteamID(&errs, "originalcrawltarget", dto.OriginalCrawlTarget)
+	validateCrawlID(&errs, "crawlid", dto.CrawlID)
+	validateLevel(&errs, "maxlevel", dto.MaxLevel, MinCrawlLevel, MaxCrawlLevel)
+	validateLevel(&errs, "currentlevel", dto.CurrentLevel, 0, dto.MaxLevel)
+	validateSteamID(&errs, "user.accdetails.steamid", dto.User.AccDetails.SteamID)
+	return errs.Err()
+}
+
+// Validate checks the crawling status belongs to a crawl
+func (dto *SaveCrawlingStatsDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateCrawlID(&errs, "crawlingstatus.crawlid", dto.CrawlingStatus.CrawlID)
+	validateSteamID(&errs, "crawlingstatus.originalcrawltarget", dto.CrawlingStatus.OriginalCrawlTarget)
+	validateLevel(&errs, "crawlingstatus.maxlevel", dto.CrawlingStatus.MaxLevel, MinCrawlLevel, MaxCrawlLevel)
+	validateLevel(&errs, "currentlevel", dto.CurrentLevel, 0, dto.CrawlingStatus.MaxLevel)
+	return errs.Err()
+}
+
+// Validate removes duplicate steamIDs and checks the rest
+func (dto *GetUsernamesFromSteamIDsInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	dto.SteamIDs = dedupe(dto.SteamIDs)
+	if len(dto.SteamIDs) == 0 {
+		errs.Add("steamids", "must not be empty")
+	} else if len(dto.SteamIDs) > MaxSteamIDsPerRequest {
+		errs.Add("steamids", "must have at most %d steamIDs", MaxSteamIDsPerRequest)
+	}
+	for _, steamID := range dto.SteamIDs {
+		if !util.IsValidFormatSteamID(steamID) {
+			errs.Add("steamids", "contains invalid steamID %q", steamID)
+		}
+	}
+	return errs.Err()
+}
+
+// Validate checks the users to crawl, SecondSteamID is optional
+// and is only set when finding the path between two users
+func (dto *CrawlUsersInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateSteamID(&errs, "firstSteamID", dto.FirstSteamID)
+	if dto.SecondSteamID != "" {
+		validateSteamID(&errs, "secondSteamID", dto.SecondSteamID)
+		if dto.SecondSteamID == dto.FirstSteamID {
+			errs.Add("secondSteamID", "must be different to firstSteamID")
+		}
+	}
+	validateLevel(&errs, "level", dto.Level, MinCrawlLevel, MaxCrawlLevel)
+	return errs.Err()
+}
+
+// Validate checks a crawl is given
+func (dto *CreateGraphInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateCrawlID(&errs, "crawlid", dto.CrawlID)
+	return errs.Err()
+}
+
+// Validate removes duplicate games and checks the rest
+func (dto *GetDetailsForGamesInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	seen := make(map[int]bool, len(dto.GameIDs))
+	unique := make([]int, 0, len(dto.GameIDs))
+	for _, gameID := range dto.GameIDs {
+		if !seen[gameID] {
+			seen[gameID] = true
+			unique = append(unique, gameID)
+		}
+		if gameID <= 0 {
+			errs.Add("gameids", "contains invalid game %d", gameID)
+		}
+	}
+	dto.GameIDs = unique
+	if len(dto.GameIDs) == 0 {
+		errs.Add("gameids", "must not be empty")
+	} else if len(dto.GameIDs) > MaxGameIDsPerRequest {
+		errs.Add("gameids", "must have at most %d games", MaxGameIDsPerRequest)
+	}
+	return errs.Err()
+}
+
+// Validate checks the user and level
+func (dto *HasBeenCrawledBeforeInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateLevel(&errs, "level", dto.Level, MinCrawlLevel, MaxCrawlLevel)
+	validateSteamID(&errs, "steamid", dto.SteamID)
+	return errs.Err()
+}
+
+// Validate checks the crawl and both users are given
+func (dto *GetShortestPathsInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateCrawlID(&errs, "crawlid", dto.CrawlID)
+	validateSteamID(&errs, "firstSteamID", dto.FirstSteamID)
+	validateSteamID(&errs, "secondSteamID", dto.SecondSteamID)
+	return errs.Err()
+}
+
+// Validate checks both users are given
+func (dto *GetSharedGamesInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateSteamID(&errs, "firstSteamID", dto.FirstSteamID)
+	validateSteamID(&errs, "secondSteamID", dto.SecondSteamID)
+	return errs.Err()
+}
+
+// Validate checks both crawls are given
+func (dto *GetCrawlDiffInputDTO) Validate() error {
+	errs := util.ValidationErrors{}
+	validateCrawlID(&errs, "oldcrawlid", dto.OldCrawlID)
+	validateCrawlID(&errs, "newcrawlid", dto.NewCrawlID)
+	return errs.Err()
+}
@@ -0,0 +1,153 @@
+package dtos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	validSteamID      = "76561197969081524"
+	otherValidSteamID = "76561197960287930"
+)
+
+func assertFieldErrors(t *testing.T, err error, fields ...string) {
+	if len(fields) == 0 {
+		assert.Nil(t, err)
+		return
+	}
+	errs, ok := err.(util.ValidationErrors)
+	assert.True(t, ok, "expected validation errors but got %v", err)
+	invalidFields := []string{}
+	for _, fieldErr := range errs {
+		invalidFields = append(invalidFields, fieldErr.Field)
+	}
+	assert.Equal(t, fields, invalidFields)
+}
+
+func TestCrawlUsersInputDTOValidate(t *testing.T) {
+	tests := []struct {
+		input  CrawlUsersInputDTO
+		fields []string
+	}{
+		{CrawlUsersInputDTO{FirstSteamID: validSteamID, Level: 2}, nil},
+		{CrawlUsersInputDTO{FirstSteamID: validSteamID, SecondSteamID: otherValidSteamID, Level: 3}, nil},
+		{CrawlUsersInputDTO{Level: 1}, []string{"firstSteamID"}},
+		{CrawlUsersInputDTO{FirstSteamID: "1234", SecondSteamID: "abc", Level: 4}, []string{"firstSteamID", "secondSteamID", "level"}},
+		{CrawlUsersInputDTO{FirstSteamID: validSteamID, SecondSteamID: validSteamID, Level: 0}, []string{"secondSteamID", "level"}},
+	}
+	for _, test := range tests {
+		assertFieldErrors(t, test.input.Validate(), test.fields...)
+	}
+}
+
+func TestHasBeenCrawledBeforeInputDTOValidate(t *testing.T) {
+	assertFieldErrors(t, (&HasBeenCrawledBeforeInputDTO{Level: 1, SteamID: validSteamID}).Validate())
+	assertFieldErrors(t, (&HasBeenCrawledBeforeInputDTO{Level: 5}).Validate(), "level", "steamid")
+}
+
+func TestGetUsernamesFromSteamIDsInputDTOValidateRemovesDuplicates(t *testing.T) {
+	input := GetUsernamesFromSteamIDsInputDTO{SteamIDs: []string{validSteamID, otherValidSteamID, validSteamID}}
+
+	steamIDs := input.SteamIDs
+
+	assertFieldErrors(t, input.Validate())
+	assert.Equal(t, []string{validSteamID, otherValidSteamID}, input.SteamIDs)
+	assert.Equal(t, []string{validSteamID, otherValidSteamID, validSteamID}, steamIDs)
+}
+
+func TestGetUsernamesFromSteamIDsInputDTOValidateLimits(t *testing.T) {
+	assertFieldErrors(t, (&GetUsernamesFromSteamIDsInputDTO{}).Validate(), "steamids")
+	assertFieldErrors(t, (&GetUsernamesFromSteamIDsInputDTO{SteamIDs: []string{validSteamID, "bad"}}).Validate(), "steamids")
+
+	tooMany := GetUsernamesFromSteamIDsInputDTO{}
+	for i := 0; i <= MaxSteamIDsPerRequest; i++ {
+		tooMany.SteamIDs = append(tooMany.SteamIDs, fmt.Sprintf("%017d", i))
+	}
+	assert.EqualError(t, tooMany.Validate(), "steamids must have at most 100 steamIDs")
+}
+
+func TestGetDetailsForGamesInputDTOValidate(t *testing.T) {
+	gameIDs := []int{10, 570, 10}
+	input := GetDetailsForGamesInputDTO{GameIDs: gameIDs}
+	assertFieldErrors(t, input.Validate())
+	assert.Equal(t, []int{10, 570}, input.GameIDs)
+	assert.Equal(t, []int{10, 570, 10}, gameIDs)
+
+	assertFieldErrors(t, (&GetDetailsForGamesInputDTO{}).Validate(), "gameids")
+	assert.EqualError(t, (&GetDetailsForGamesInputDTO{GameIDs: []int{10, -1}}).Validate(), "gameids contains invalid game -1")
+
+	tooMany := GetDetailsForGamesInputDTO{}
+	for i := 1; i <= MaxGameIDsPerRequest+1; i++ {
+		tooMany.GameIDs = append(tooMany.GameIDs, i)
+	}
+	assertFieldErrors(t, tooMany.Validate(), "gameids")
+}
+
+func TestSaveUserDTOValidate(t *testing.T) {
+	input := SaveUserDTO{
+		OriginalCrawlTarget: validSteamID,
+		CrawlID:             "abc",
+		CurrentLevel:        2,
+		MaxLevel:            2,
+		User:                common.UserDocument{AccDetails: common.AccDetailsDocument{SteamID: otherValidSteamID}},
+	}
+	assertFieldErrors(t, input.Validate())
+
+	input.CurrentLevel = 3
+	input.CrawlID = strings.Repeat("a", MaxCrawlIDLength+1)
+	input.User.AccDetails.SteamID = ""
+	assertFieldErrors(t, input.Validate(), "crawlid", "currentlevel", "user.accdetails.steamid")
+}
+
+func TestSaveCrawlingStatsDTOValidate(t *testing.T) {
+	input := SaveCrawlingStatsDTO{
+		CurrentLevel:   1,
+		CrawlingStatus: common.CrawlingStatus{CrawlID: "abc", OriginalCrawlTarget: validSteamID, MaxLevel: 2},
+	}
+	assertFieldErrors(t, input.Validate())
+	assertFieldErrors(t, (&SaveCrawlingStatsDTO{}).Validate(), "crawlingstatus.crawlid", "crawlingstatus.originalcrawltarget", "crawlingstatus.maxlevel")
+}
+
+func TestCrawlInputDTOsValidate(t *testing.T) {
+	assertFieldErrors(t, (&CreateGraphInputDTO{CrawlID: "abc"}).Validate())
+	assertFieldErrors(t, (&CreateGraphInputDTO{}).Validate(), "crawlid")
+	assertFieldErrors(t, (&GetShortestPathsInputDTO{CrawlID: "abc", FirstSteamID: validSteamID, SecondSteamID: otherValidSteamID}).Validate())
+	assertFieldErrors(t, (&GetShortestPathsInputDTO{FirstSteamID: validSteamID}).Validate(), "crawlid", "secondSteamID")
+	assertFieldErrors(t, (&GetSharedGamesInputDTO{FirstSteamID: validSteamID, SecondSteamID: otherValidSteamID}).Validate())
+	assertFieldErrors(t, (&GetSharedGamesInputDTO{SecondSteamID: "x"}).Validate(), "firstSteamID", "secondSteamID")
+	assertFieldErrors(t, (&GetCrawlDiffInputDTO{OldCrawlID: "a", NewCrawlID: "b"}).Validate())
+	assertFieldErrors(t, (&GetCrawlDiffInputDTO{}).Validate(), "oldcrawlid", "newcrawlid")
+}
+
+func TestDecodeAndValidateRejectsUnknownSaveUserDTOFields(t *testing.T) {
+	body := fmt.Sprintf(`{"originalcrawltarget": %q, "crawlid": "abc", "currentlevel": 1, "maxlevel": 2, "user": {"accdetails": {"steamid": %q}}`, validSteamID, otherValidSteamID)
+	for _, test := range []struct {
+		body       string
+		statusCode int
+	}{
+		{body: body + "}", statusCode: http.StatusOK},
+		{body: body + `, "bogus": 1}`, statusCode: http.StatusBadRequest},
+		{body: strings.Replace(body, "originalcrawltarget", "orginalcrawltarget", 1) + "}", statusCode: http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/saveuser", strings.NewReader(test.body))
+		input := SaveUserDTO{}
+
+		err := util.DecodeAndValidate(w, req, &input, 0)
+
+		assert.Equal(t, test.statusCode == http.StatusOK, err == nil, test.body)
+		assert.Equal(t, test.statusCode, w.Code, test.body)
+		if err == nil {
+			assert.Equal(t, validSteamID, input.OriginalCrawlTarget)
+		} else {
+			assert.Contains(t, w.Body.String(), "bogus")
+		}
+	}
+}
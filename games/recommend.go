@@ -0,0 +1,133 @@
+package games
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// RecommendOptions configures Recommend
+type RecommendOptions struct {
+	// N is the maximum number of recommendations, all are returned if 0
+	N int
+	// MinOwners is the number of friends that must own a game
+	// before it is recommended
+	MinOwners int
+	// SimilarityWeight is how much more a friend with an identical
+	// library counts for than a friend with nothing in common
+	SimilarityWeight float64
+	// Details is used to name each game. When set, games without
+	// details are not recommended
+	Details DetailsLookup
+}
+
+// DefaultRecommendOptions recommends ten games owned by at least two
+// friends, with similar friends counting for up to three times as much
+var DefaultRecommendOptions = RecommendOptions{
+	N:                10,
+	MinOwners:        2,
+	SimilarityWeight: 2,
+}
+
+// Recommendation is a game a user does not own that their friends play
+type Recommendation struct {
+	AppID int     `json:"appid"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+	// Owners is the number of the user's friends that own the game
+	Owners int `json:"owners"`
+	// AveragePlaytime is the average playtime of owners in minutes
+	AveragePlaytime int    `json:"averageplaytime"`
+	Explanation     string `json:"explanation"`
+}
+
+// Recommend suggests games a user does not own based on their crawled
+// friends' libraries. Each friend adds the log of their playtime in hours
+// to a game's score, so no single friend dominates, weighted by how
+// similar their library is to the user's. Ties are broken by appID
+func Recommend(data common.UsersGraphData, steamID string, opts RecommendOptions) ([]Recommendation, error) {
+	byID := make(map[string]common.UserDocument)
+	for _, user := range data.DistinctUsers() {
+		byID[user.AccDetails.SteamID] = user
+	}
+	target, exists := byID[steamID]
+	if !exists {
+		return nil, fmt.Errorf("user %s is not in the crawl", steamID)
+	}
+	owned := playtimes(target)
+
+	type candidate struct {
+		score         float64
+		owners        int
+		totalPlaytime int
+	}
+	candidates := make(map[int]*candidate)
+	seen := map[string]bool{steamID: true}
+	for _, friendID := range target.FriendIDs {
+		friend, exists := byID[friendID]
+		if !exists || seen[friendID] {
+			continue
+		}
+		seen[friendID] = true
+
+		friendPlaytimes := playtimes(friend)
+		weight := 1 + opts.SimilarityWeight*cosine(owned, friendPlaytimes)
+		for appID, playtime := range friendPlaytimes {
+			if _, alreadyOwned := owned[appID]; alreadyOwned {
+				continue
+			}
+			c, exists := candidates[appID]
+			if !exists {
+				c = &candidate{}
+				candidates[appID] = c
+			}
+			c.owners++
+			c.totalPlaytime += playtime
+			c.score += weight * math.Log1p(float64(playtime)/60)
+		}
+	}
+
+	recommendations := []Recommendation{}
+	for appID, c := range candidates {
+		if c.owners < opts.MinOwners {
+			continue
+		}
+		recommendation := Recommendation{
+			AppID:           appID,
+			Score:           c.score,
+			Owners:          c.owners,
+			AveragePlaytime: c.totalPlaytime / c.owners,
+		}
+		if opts.Details != nil {
+			details, exists := opts.Details(appID)
+			if !exists {
+				continue
+			}
+			recommendation.Name = details.Name
+		}
+		recommendation.Explanation = explain(recommendation)
+		recommendations = append(recommendations, recommendation)
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].AppID < recommendations[j].AppID
+	})
+	if opts.N > 0 && len(recommendations) > opts.N {
+		recommendations = recommendations[:opts.N]
+	}
+	return recommendations, nil
+}
+
+// explain describes why a game was recommended e.g. "owned by 7 friends, 120h avg"
+func explain(recommendation Recommendation) string {
+	friends := "friends"
+	if recommendation.Owners == 1 {
+		friends = "friend"
+	}
+	return fmt.Sprintf("owned by %d %s, %dh avg", recommendation.Owners, friends, recommendation.AveragePlaytime/60)
+}
@@ -0,0 +1,77 @@
+package games
+
+import (
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRecommendData() common.UsersGraphData {
+	return common.UsersGraphData{
+		UserDetails: newTestUserWithFriends("root", []string{"alice", "bob", "carol", "erin"}, game(1, 600, 0)),
+		FriendDetails: []common.UsersGraphInformation{
+			newTestUserWithFriends("alice", nil, game(1, 600, 0), game(2, 6000, 0), game(3, 60, 0)),
+			newTestUserWithFriends("bob", nil, game(2, 60, 0), game(3, 600, 0), game(4, 60000, 0)),
+			newTestUserWithFriends("carol", nil, game(1, 60, 0), game(3, 1200, 0)),
+			// not a friend of root so ignored
+			newTestUserWithFriends("dave", nil, game(5, 6000, 0), game(5, 6000, 0)),
+		},
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	recommendations, err := Recommend(newTestRecommendData(), "root", DefaultRecommendOptions)
+
+	assert.Nil(t, err)
+	assert.Len(t, recommendations, 2, "games owned by one friend or by root should not be recommended")
+	assert.Equal(t, 3, recommendations[0].AppID)
+	assert.Equal(t, 3, recommendations[0].Owners)
+	assert.Equal(t, 620, recommendations[0].AveragePlaytime)
+	assert.Equal(t, "owned by 3 friends, 10h avg", recommendations[0].Explanation)
+	assert.Equal(t, 2, recommendations[1].AppID)
+	assert.Greater(t, recommendations[0].Score, recommendations[1].Score)
+}
+
+func TestRecommendWeightsSimilarFriends(t *testing.T) {
+	unweighted, err := Recommend(newTestRecommendData(), "root", RecommendOptions{})
+	assert.Nil(t, err)
+	weighted, err := Recommend(newTestRecommendData(), "root", RecommendOptions{SimilarityWeight: 100})
+	assert.Nil(t, err)
+
+	// bob plays game 4 the most but shares nothing with root
+	assert.Equal(t, 4, unweighted[0].AppID)
+	assert.Equal(t, 4, weighted[len(weighted)-1].AppID)
+	assert.Equal(t, "owned by 1 friend, 1000h avg", weighted[len(weighted)-1].Explanation)
+}
+
+func TestRecommendIsDeterministic(t *testing.T) {
+	first, _ := Recommend(newTestRecommendData(), "root", RecommendOptions{})
+	second, _ := Recommend(newTestRecommendData(), "root", RecommendOptions{})
+
+	assert.Equal(t, first, second)
+	assert.Len(t, first, 3)
+}
+
+func TestRecommendUsesDetails(t *testing.T) {
+	opts := DefaultRecommendOptions
+	opts.Details = DetailsFromSlice([]common.BareGameInfo{{AppID: 2, Name: "Team Fortress Classic"}})
+
+	recommendations, err := Recommend(newTestRecommendData(), "root", opts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Recommendation{{
+		AppID:           2,
+		Name:            "Team Fortress Classic",
+		Score:           recommendations[0].Score,
+		Owners:          2,
+		AveragePlaytime: 3030,
+		Explanation:     "owned by 2 friends, 50h avg",
+	}}, recommendations)
+}
+
+func TestRecommendReturnsAnErrorForUnknownUsers(t *testing.T) {
+	_, err := Recommend(newTestRecommendData(), "erin", DefaultRecommendOptions)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package games
+
+import (
+	"math"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// SharedGame is a game owned by both of two users along with
+// how long (in minutes) each of them has played it
+type SharedGame struct {
+	AppID          int `json:"appid"`
+	FirstPlaytime  int `json:"firstplaytime"`
+	SecondPlaytime int `json:"secondplaytime"`
+}
+
+// Similarity is how similar the game libraries of two users are
+type Similarity struct {
+	FirstSteamID  string `json:"firststeamid"`
+	SecondSteamID string `json:"secondsteamid"`
+	SharedGames   int    `json:"sharedgames"`
+	// Jaccard is the number of shared games divided by the number of
+	// games owned by either user, between 0 and 1
+	Jaccard float64 `json:"jaccard"`
+	// Cosine is the cosine similarity of the users' playtimes, between 0
+	// and 1, so games both users have played a lot count for more
+	Cosine float64 `json:"cosine"`
+}
+
+func playtimes(user common.UserDocument) map[int]int {
+	byAppID := make(map[int]int, len(user.GamesOwned))
+	for _, game := range user.GamesOwned {
+		byAppID[game.AppID] = game.Playtime_Forever
+	}
+	return byAppID
+}
+
+// SharedGames returns the games owned by both users, most
+// played between them first
+func SharedGames(first, second common.UserDocument) []SharedGame {
+	secondPlaytimes := playtimes(second)
+	shared := []SharedGame{}
+	for appID, playtime := range playtimes(first) {
+		if secondPlaytime, exists := secondPlaytimes[appID]; exists {
+			shared = append(shared, SharedGame{
+				AppID:          appID,
+				FirstPlaytime:  playtime,
+				SecondPlaytime: secondPlaytime,
+			})
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		a := shared[i].FirstPlaytime + shared[i].SecondPlaytime
+		b := shared[j].FirstPlaytime + shared[j].SecondPlaytime
+		if a != b {
+			return a > b
+		}
+		return shared[i].AppID < shared[j].AppID
+	})
+	return shared
+}
+
+// Jaccard returns the Jaccard similarity of two users' game libraries
+func Jaccard(first, second common.UserDocument) float64 {
+	return jaccard(playtimes(first), playtimes(second))
+}
+
+func jaccard(first, second map[int]int) float64 {
+	shared := 0
+	for appID := range first {
+		if _, exists := second[appID]; exists {
+			shared++
+		}
+	}
+	union := len(first) + len(second) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// CosineSimilarity returns the cosine similarity of two users' playtimes,
+// 0 if either has no playtime at all
+func CosineSimilarity(first, second common.UserDocument) float64 {
+	return cosine(playtimes(first), playtimes(second))
+}
+
+func cosine(first, second map[int]int) float64 {
+	dot, firstNorm, secondNorm := 0.0, 0.0, 0.0
+	for appID, playtime := range first {
+		firstNorm += float64(playtime) * float64(playtime)
+		dot += float64(playtime) * float64(second[appID])
+	}
+	for _, playtime := range second {
+		secondNorm += float64(playtime) * float64(playtime)
+	}
+	if firstNorm == 0 || secondNorm == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(firstNorm) * math.Sqrt(secondNorm))
+}
+
+// Compare returns the similarity of two users
+func Compare(first, second common.UserDocument) Similarity {
+	return compare(first.AccDetails.SteamID, second.AccDetails.SteamID, playtimes(first), playtimes(second))
+}
+
+func compare(firstSteamID, secondSteamID string, first, second map[int]int) Similarity {
+	shared := 0
+	for appID := range first {
+		if _, exists := second[appID]; exists {
+			shared++
+		}
+	}
+	return Similarity{
+		FirstSteamID:  firstSteamID,
+		SecondSteamID: secondSteamID,
+		SharedGames:   shared,
+		Jaccard:       jaccard(first, second),
+		Cosine:        cosine(first, second),
+	}
+}
+
+// NetworkSimilarity compares every pair of distinct users in a crawl, in the
+// order the users appear. This is quadratic in the number of users
+func NetworkSimilarity(data common.UsersGraphData) []Similarity {
+	networkUsers := data.DistinctUsers()
+	userPlaytimes := make([]map[int]int, len(networkUsers))
+	for i, user := range networkUsers {
+		userPlaytimes[i] = playtimes(user)
+	}
+
+	similarities := make([]Similarity, 0, len(networkUsers)*(len(networkUsers)-1)/2)
+	for i := range networkUsers {
+		for j := i + 1; j < len(networkUsers); j++ {
+			similarities = append(similarities, compare(
+				networkUsers[i].AccDetails.SteamID, networkUsers[j].AccDetails.SteamID,
+				userPlaytimes[i], userPlaytimes[j]))
+		}
+	}
+	return similarities
+}
+
+// MostSimilarFriends ranks a user's crawled friends by how similar their
+// libraries are, by cosine similarity and then Jaccard similarity. At most
+// n friends are returned, all of them if n is 0
+func MostSimilarFriends(data common.UsersGraphData, steamID string, n int) []Similarity {
+	byID := make(map[string]common.UserDocument)
+	for _, user := range data.DistinctUsers() {
+		byID[user.AccDetails.SteamID] = user
+	}
+	target, exists := byID[steamID]
+	if !exists {
+		return []Similarity{}
+	}
+
+	targetPlaytimes := playtimes(target)
+	seen := make(map[string]bool)
+	similarities := []Similarity{}
+	for _, friendID := range target.FriendIDs {
+		friend, exists := byID[friendID]
+		if !exists || seen[friendID] || friendID == steamID {
+			continue
+		}
+		seen[friendID] = true
+		similarities = append(similarities, compare(steamID, friendID, targetPlaytimes, playtimes(friend)))
+	}
+
+	sort.Slice(similarities, func(i, j int) bool {
+		a, b := similarities[i], similarities[j]
+		if a.Cosine != b.Cosine {
+			return a.Cosine > b.Cosine
+		}
+		if a.Jaccard != b.Jaccard {
+			return a.Jaccard > b.Jaccard
+		}
+		return a.SecondSteamID < b.SecondSteamID
+	})
+	if n > 0 && len(similarities) > n {
+		similarities = similarities[:n]
+	}
+	return similarities
+}
@@ -0,0 +1,69 @@
+package games
+
+import (
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUserWithFriends(steamID string, friendIDs []string, games ...common.GameOwnedDocument) common.UsersGraphInformation {
+	user := newTestUser(steamID, games...)
+	user.User.FriendIDs = friendIDs
+	return user
+}
+
+func TestSharedGames(t *testing.T) {
+	data := newTestGamesData()
+
+	shared := SharedGames(data.UserDetails.User, data.FriendDetails[0].User)
+
+	assert.Equal(t, []SharedGame{
+		{AppID: 30, FirstPlaytime: 60, SecondPlaytime: 600},
+		{AppID: 10, FirstPlaytime: 100, SecondPlaytime: 100},
+	}, shared)
+}
+
+func TestJaccardAndCosineSimilarity(t *testing.T) {
+	first := newTestUser("a", game(1, 10, 0), game(2, 10, 0)).User
+	second := newTestUser("b", game(2, 10, 0), game(3, 10, 0)).User
+	identical := newTestUser("c", game(1, 20, 0), game(2, 20, 0)).User
+	empty := newTestUser("d").User
+
+	assert.InDelta(t, 1.0/3.0, Jaccard(first, second), 1e-9)
+	assert.InDelta(t, 0.5, CosineSimilarity(first, second), 1e-9)
+	assert.InDelta(t, 1.0, CosineSimilarity(first, identical), 1e-9)
+	assert.Equal(t, 0.0, Jaccard(empty, empty))
+	assert.Equal(t, 0.0, CosineSimilarity(first, empty))
+}
+
+func TestNetworkSimilarityComparesEveryPair(t *testing.T) {
+	similarities := NetworkSimilarity(newTestGamesData())
+
+	assert.Len(t, similarities, 6)
+	assert.Equal(t, "root", similarities[0].FirstSteamID)
+	assert.Equal(t, "alice", similarities[0].SecondSteamID)
+	assert.Equal(t, 2, similarities[0].SharedGames)
+	assert.InDelta(t, 2.0/3.0, similarities[0].Jaccard, 1e-9)
+}
+
+func TestMostSimilarFriends(t *testing.T) {
+	data := common.UsersGraphData{
+		UserDetails: newTestUserWithFriends("root", []string{"alice", "bob", "carol", "erin"}, game(1, 100, 0), game(2, 10, 0)),
+		FriendDetails: []common.UsersGraphInformation{
+			newTestUserWithFriends("alice", nil, game(2, 100, 0)),
+			newTestUserWithFriends("bob", nil, game(1, 50, 0), game(2, 5, 0)),
+			newTestUserWithFriends("carol", nil, game(3, 100, 0)),
+			newTestUserWithFriends("dave", nil, game(1, 100, 0), game(2, 10, 0)),
+		},
+	}
+
+	similar := MostSimilarFriends(data, "root", 2)
+
+	assert.Len(t, similar, 2)
+	assert.Equal(t, "bob", similar[0].SecondSteamID)
+	assert.InDelta(t, 1.0, similar[0].Cosine, 1e-9)
+	assert.Equal(t, "alice", similar[1].SecondSteamID)
+	assert.Len(t, MostSimilarFriends(data, "root", 0), 3, "uncrawled and non-friends should be skipped")
+	assert.Empty(t, MostSimilarFriends(data, "erin", 0))
+}
@@ -0,0 +1,155 @@
+package games
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// RankBy is the measure games are ranked by across a friend network
+type RankBy string
+
+const (
+	// ByTotalPlaytime ranks games by the playtime of every owner combined
+	ByTotalPlaytime RankBy = "totalplaytime"
+	// ByOwnerCount ranks games by how many users own them
+	ByOwnerCount RankBy = "ownercount"
+	// ByRecentPlaytime ranks games by playtime over the last two weeks
+	ByRecentPlaytime RankBy = "recentplaytime"
+
+	// DefaultTopN is the number of games saved in UsersGraphData.TopGameDetails
+	DefaultTopN = 10
+)
+
+// DetailsLookup returns the details for a game if they are known
+type DetailsLookup func(appID int) (common.BareGameInfo, bool)
+
+// DetailsFromSlice creates a DetailsLookup from game details, such
+// as those returned from POST /getdetailsforgames
+func DetailsFromSlice(details []common.BareGameInfo) DetailsLookup {
+	byAppID := make(map[int]common.BareGameInfo, len(details))
+	for _, game := range details {
+		byAppID[game.AppID] = game
+	}
+	return func(appID int) (common.BareGameInfo, bool) {
+		game, exists := byAppID[appID]
+		return game, exists
+	}
+}
+
+// TopGamesOptions configures TopGames
+type TopGamesOptions struct {
+	RankBy RankBy
+	// TopN is how many games are returned, DefaultTopN if 0
+	TopN int
+	// Details is used to name each game. When set, games without details are
+	// skipped and the next highest ranked game takes their place
+	Details DetailsLookup
+}
+
+// GameStats is a game's popularity across a friend network. Playtimes are
+// in minutes as returned by the steam web API
+type GameStats struct {
+	AppID          int    `json:"appid"`
+	Name           string `json:"name"`
+	Owners         int    `json:"owners"`
+	TotalPlaytime  int    `json:"totalplaytime"`
+	RecentPlaytime int    `json:"recentplaytime"`
+	// OwnershipPercentage is the percentage of users
+	// in the network that own the game
+	OwnershipPercentage float64 `json:"ownershippercentage"`
+}
+
+// Aggregate totals ownership and playtime for every game owned
+// by a user in the crawl, ordered by appID
+func Aggregate(data common.UsersGraphData) []GameStats {
+	networkUsers := data.DistinctUsers()
+	byAppID := make(map[int]*GameStats)
+	for _, user := range networkUsers {
+		for _, game := range user.GamesOwned {
+			stats, exists := byAppID[game.AppID]
+			if !exists {
+				stats = &GameStats{AppID: game.AppID}
+				byAppID[game.AppID] = stats
+			}
+			stats.Owners++
+			stats.TotalPlaytime += game.Playtime_Forever
+			stats.RecentPlaytime += game.Playtime_2Weeks
+		}
+	}
+
+	allStats := make([]GameStats, 0, len(byAppID))
+	for _, stats := range byAppID {
+		stats.OwnershipPercentage = float64(stats.Owners) / float64(len(networkUsers)) * 100
+		allStats = append(allStats, *stats)
+	}
+	sort.Slice(allStats, func(i, j int) bool {
+		return allStats[i].AppID < allStats[j].AppID
+	})
+	return allStats
+}
+
+// TopGames ranks the games across a crawl and returns the top N. Ties are
+// broken by the other measures and then by appID so the ranking is stable
+func TopGames(data common.UsersGraphData, opts TopGamesOptions) ([]GameStats, error) {
+	measures := map[RankBy]func(GameStats) int{
+		ByTotalPlaytime:  func(s GameStats) int { return s.TotalPlaytime },
+		ByOwnerCount:     func(s GameStats) int { return s.Owners },
+		ByRecentPlaytime: func(s GameStats) int { return s.RecentPlaytime },
+	}
+	primary, exists := measures[opts.RankBy]
+	if !exists {
+		return nil, fmt.Errorf("unknown ranking: %q", opts.RankBy)
+	}
+	tieBreakers := []func(GameStats) int{primary}
+	for _, rankBy := range []RankBy{ByTotalPlaytime, ByOwnerCount, ByRecentPlaytime} {
+		if rankBy != opts.RankBy {
+			tieBreakers = append(tieBreakers, measures[rankBy])
+		}
+	}
+
+	topN := opts.TopN
+	if topN <= 0 {
+		topN = DefaultTopN
+	}
+
+	ranked := Aggregate(data)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		for _, measure := range tieBreakers {
+			if a, b := measure(ranked[i]), measure(ranked[j]); a != b {
+				return a > b
+			}
+		}
+		return false
+	})
+
+	topGames := []GameStats{}
+	for _, stats := range ranked {
+		if len(topGames) == topN {
+			break
+		}
+		if opts.Details != nil {
+			details, exists := opts.Details(stats.AppID)
+			if !exists {
+				continue
+			}
+			stats.Name = details.Name
+		}
+		topGames = append(topGames, stats)
+	}
+	return topGames, nil
+}
+
+// TopGameDetails converts ranked games into the format
+// saved in UsersGraphData.TopGameDetails
+func TopGameDetails(topGames []GameStats) []common.BareGameInfo {
+	details := make([]common.BareGameInfo, len(topGames))
+	for i, game := range topGames {
+		details[i] = common.BareGameInfo{
+			AppID: game.AppID,
+			Name:  game.Name,
+		}
+	}
+	return details
+}
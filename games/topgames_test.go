@@ -0,0 +1,106 @@
+package games
+
+import (
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUser(steamID string, games ...common.GameOwnedDocument) common.UsersGraphInformation {
+	return common.UsersGraphInformation{
+		User: common.UserDocument{
+			AccDetails: common.AccDetailsDocument{SteamID: steamID},
+			GamesOwned: games,
+		},
+	}
+}
+
+func game(appID, playtime, recentPlaytime int) common.GameOwnedDocument {
+	return common.GameOwnedDocument{
+		AppID:            appID,
+		Playtime_Forever: playtime,
+		Playtime_2Weeks:  recentPlaytime,
+	}
+}
+
+// newTestGamesData returns a crawl of four users where game 10 is owned by
+// everyone, game 20 has the most playtime and game 30 the most recent playtime
+func newTestGamesData() common.UsersGraphData {
+	return common.UsersGraphData{
+		UserDetails: newTestUser("root", game(10, 100, 0), game(20, 5000, 0), game(30, 60, 60)),
+		FriendDetails: []common.UsersGraphInformation{
+			newTestUser("alice", game(10, 100, 0), game(30, 600, 600)),
+			newTestUser("bob", game(10, 100, 10), game(40, 50, 0)),
+			newTestUser("carol", game(10, 100, 0)),
+			// duplicates are only counted once
+			newTestUser("carol", game(10, 100, 0)),
+		},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	stats := Aggregate(newTestGamesData())
+
+	assert.Equal(t, []GameStats{
+		{AppID: 10, Owners: 4, TotalPlaytime: 400, RecentPlaytime: 10, OwnershipPercentage: 100},
+		{AppID: 20, Owners: 1, TotalPlaytime: 5000, OwnershipPercentage: 25},
+		{AppID: 30, Owners: 2, TotalPlaytime: 660, RecentPlaytime: 660, OwnershipPercentage: 50},
+		{AppID: 40, Owners: 1, TotalPlaytime: 50, OwnershipPercentage: 25},
+	}, stats)
+}
+
+func TestTopGamesByEachMeasure(t *testing.T) {
+	data := newTestGamesData()
+	expectedOrders := map[RankBy][]int{
+		ByTotalPlaytime:  {20, 30, 10, 40},
+		ByOwnerCount:     {10, 30, 20, 40},
+		ByRecentPlaytime: {30, 10, 20, 40},
+	}
+
+	for rankBy, expectedOrder := range expectedOrders {
+		topGames, err := TopGames(data, TopGamesOptions{RankBy: rankBy, TopN: 10})
+
+		assert.Nil(t, err)
+		appIDs := []int{}
+		for _, game := range topGames {
+			appIDs = append(appIDs, game.AppID)
+		}
+		assert.Equal(t, expectedOrder, appIDs, string(rankBy))
+	}
+}
+
+func TestTopGamesBackFillsGamesWithoutDetails(t *testing.T) {
+	details := DetailsFromSlice([]common.BareGameInfo{
+		{AppID: 10, Name: "Team Fortress Classic"},
+		{AppID: 30, Name: "Day of Defeat"},
+		{AppID: 40, Name: "Deathmatch Classic"},
+	})
+
+	topGames, err := TopGames(newTestGamesData(), TopGamesOptions{RankBy: ByTotalPlaytime, TopN: 2, Details: details})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []common.BareGameInfo{
+		{AppID: 30, Name: "Day of Defeat"},
+		{AppID: 10, Name: "Team Fortress Classic"},
+	}, TopGameDetails(topGames))
+}
+
+func TestTopGamesRejectsUnknownRanking(t *testing.T) {
+	_, err := TopGames(newTestGamesData(), TopGamesOptions{RankBy: "vibes"})
+
+	assert.Error(t, err)
+}
+
+func TestTopGamesDefaultsToDefaultTopN(t *testing.T) {
+	data := newTestUser("root")
+	for appID := 1; appID <= DefaultTopN+5; appID++ {
+		data.User.GamesOwned = append(data.User.GamesOwned, game(appID, appID, 0))
+	}
+
+	topGames, err := TopGames(common.UsersGraphData{UserDetails: data}, TopGamesOptions{RankBy: ByTotalPlaytime})
+
+	assert.Nil(t, err)
+	assert.Len(t, topGames, DefaultTopN)
+	assert.Equal(t, DefaultTopN+5, topGames[0].AppID)
+}
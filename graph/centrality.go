@@ -0,0 +1,273 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// PageRankOptions configures PageRank, fields left
+// at 0 are taken from DefaultPageRankOptions
+type PageRankOptions struct {
+	// Damping is the probability of following an edge rather than
+	// jumping to a random node
+	Damping float64
+	// Tolerance stops iterating once the total change in scores
+	// between iterations falls below it
+	Tolerance     float64
+	MaxIterations int
+}
+
+// DefaultPageRankOptions are the standard PageRank parameters
+var DefaultPageRankOptions = PageRankOptions{
+	Damping:       0.85,
+	Tolerance:     1e-6,
+	MaxIterations: 100,
+}
+
+// CentralityOptions configures Centrality
+type CentralityOptions struct {
+	// BetweennessSamples is the number of source nodes used to approximate
+	// betweenness, 0 computes it exactly which is O(nodes*edges)
+	BetweennessSamples int
+	// Seed chooses the sampled source nodes
+	Seed     int64
+	PageRank PageRankOptions
+}
+
+// DegreeCentrality returns each user's number of friends divided by
+// the most friends they could have in the graph
+func (g *Graph) DegreeCentrality() map[string]float64 {
+	return g.scoresByID(g.degreeCentrality())
+}
+
+func (g *Graph) degreeCentrality() []float64 {
+	scores := make([]float64, len(g.nodes))
+	if len(g.nodes) < 2 {
+		return scores
+	}
+	for i, neighbours := range g.adj {
+		scores[i] = float64(len(neighbours)) / float64(len(g.nodes)-1)
+	}
+	return scores
+}
+
+// BetweennessCentrality returns the normalised fraction of shortest paths
+// between other users that pass through each user, using Brandes' algorithm
+func (g *Graph) BetweennessCentrality() map[string]float64 {
+	return g.scoresByID(g.betweennessCentrality(0, 0))
+}
+
+// ApproximateBetweennessCentrality estimates betweenness from shortest paths
+// starting at samples randomly chosen users, for graphs too large for the
+// exact computation. The same seed always chooses the same users
+func (g *Graph) ApproximateBetweennessCentrality(samples int, seed int64) map[string]float64 {
+	return g.scoresByID(g.betweennessCentrality(samples, seed))
+}
+
+func (g *Graph) betweennessCentrality(samples int, seed int64) []float64 {
+	n := len(g.nodes)
+	scores := make([]float64, n)
+	if n < 3 {
+		return scores
+	}
+
+	sources := make([]int, n)
+	for i := range sources {
+		sources[i] = i
+	}
+	if samples > 0 && samples < n {
+		sources = rand.New(rand.NewSource(seed)).Perm(n)[:samples]
+	}
+
+	dist := make([]int, n)
+	pathCount := make([]float64, n)
+	dependency := make([]float64, n)
+	order := make([]int, 0, n)
+	for _, s := range sources {
+		for i := range dist {
+			dist[i] = -1
+			pathCount[i] = 0
+			dependency[i] = 0
+		}
+		dist[s] = 0
+		pathCount[s] = 1
+		order = append(order[:0], s)
+		for head := 0; head < len(order); head++ {
+			u := order[head]
+			for _, v := range g.adj[u] {
+				if dist[v] == -1 {
+					dist[v] = dist[u] + 1
+					order = append(order, v)
+				}
+				if dist[v] == dist[u]+1 {
+					pathCount[v] += pathCount[u]
+				}
+			}
+		}
+		// Accumulate dependencies in order of decreasing distance
+		for k := len(order) - 1; k > 0; k-- {
+			w := order[k]
+			for _, v := range g.adj[w] {
+				if dist[v] == dist[w]-1 {
+					dependency[v] += pathCount[v] / pathCount[w] * (1 + dependency[w])
+				}
+			}
+			scores[w] += dependency[w]
+		}
+	}
+
+	// Each pair is counted from both ends in an undirected graph
+	scale := 1 / float64((n-1)*(n-2))
+	if len(sources) < n {
+		scale *= float64(n) / float64(len(sources))
+	}
+	for i := range scores {
+		scores[i] *= scale
+	}
+	return scores
+}
+
+// ClosenessCentrality returns how close each user is to every user they can
+// reach, scaled by the fraction of the graph they can reach (Wasserman and
+// Faust) so users in small disconnected components do not score highly
+func (g *Graph) ClosenessCentrality() map[string]float64 {
+	return g.scoresByID(g.closenessCentrality())
+}
+
+func (g *Graph) closenessCentrality() []float64 {
+	n := len(g.nodes)
+	scores := make([]float64, n)
+	if n < 2 {
+		return scores
+	}
+	dist := make([]int, n)
+	queue := make([]int, 0, n)
+	for s := range g.nodes {
+		for i := range dist {
+			dist[i] = -1
+		}
+		dist[s] = 0
+		queue = append(queue[:0], s)
+		totalDistance := 0
+		for head := 0; head < len(queue); head++ {
+			u := queue[head]
+			totalDistance += dist[u]
+			for _, v := range g.adj[u] {
+				if dist[v] == -1 {
+					dist[v] = dist[u] + 1
+					queue = append(queue, v)
+				}
+			}
+		}
+		reachable := float64(len(queue) - 1)
+		if totalDistance > 0 {
+			scores[s] = (reachable / float64(totalDistance)) * (reachable / float64(n-1))
+		}
+	}
+	return scores
+}
+
+// PageRank returns the stationary probability of a random walk over friendships
+// being at each user. Users with no friends share their score with everyone
+func (g *Graph) PageRank(opts PageRankOptions) map[string]float64 {
+	return g.scoresByID(g.pageRank(opts))
+}
+
+// withDefaults returns opts with its unset fields
+// taken from DefaultPageRankOptions
+func (opts PageRankOptions) withDefaults() PageRankOptions {
+	if opts.Damping == 0 {
+		opts.Damping = DefaultPageRankOptions.Damping
+	}
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultPageRankOptions.Tolerance
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultPageRankOptions.MaxIterations
+	}
+	return opts
+}
+
+func (g *Graph) pageRank(opts PageRankOptions) []float64 {
+	opts = opts.withDefaults()
+	n := len(g.nodes)
+	scores := make([]float64, n)
+	if n == 0 {
+		return scores
+	}
+	for i := range scores {
+		scores[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iteration := 0; iteration < opts.MaxIterations; iteration++ {
+		dangling := 0.0
+		for i, neighbours := range g.adj {
+			if len(neighbours) == 0 {
+				dangling += scores[i]
+			}
+		}
+		base := (1-opts.Damping)/float64(n) + opts.Damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, neighbours := range g.adj {
+			share := opts.Damping * scores[i] / float64(len(neighbours))
+			for _, j := range neighbours {
+				next[j] += share
+			}
+		}
+
+		change := 0.0
+		for i := range scores {
+			change += math.Abs(next[i] - scores[i])
+		}
+		scores, next = next, scores
+		if change < opts.Tolerance {
+			break
+		}
+	}
+	return scores
+}
+
+// Centrality computes every centrality metric for each user in the graph
+func (g *Graph) Centrality(opts CentralityOptions) map[string]common.CentralityScores {
+	degree := g.degreeCentrality()
+	betweenness := g.betweennessCentrality(opts.BetweennessSamples, opts.Seed)
+	closeness := g.closenessCentrality()
+	pageRank := g.pageRank(opts.PageRank)
+
+	scores := make(map[string]common.CentralityScores, len(g.nodes))
+	for i, node := range g.nodes {
+		scores[node.SteamID] = common.CentralityScores{
+			Degree:      degree[i],
+			Betweenness: betweenness[i],
+			Closeness:   closeness[i],
+			PageRank:    pageRank[i],
+		}
+	}
+	return scores
+}
+
+// AttachCentrality sets the centrality scores of every user in a crawl
+// that has scores, so they are included in the processed graph data
+func AttachCentrality(data *common.UsersGraphData, scores map[string]common.CentralityScores) {
+	attach := func(user *common.UsersGraphInformation) {
+		if userScores, exists := scores[user.User.AccDetails.SteamID]; exists {
+			user.Centrality = &userScores
+		}
+	}
+	attach(&data.UserDetails)
+	for i := range data.FriendDetails {
+		attach(&data.FriendDetails[i])
+	}
+}
+
+func (g *Graph) scoresByID(scores []float64) map[string]float64 {
+	byID := make(map[string]float64, len(scores))
+	for i, score := range scores {
+		byID[g.nodes[i].SteamID] = score
+	}
+	return byID
+}
@@ -0,0 +1,191 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newStarGraph returns a graph with "centre" connected to each of the leaves
+func newStarGraph(leaves int) *Graph {
+	g := New()
+	g.AddNode(Node{SteamID: "centre"})
+	for i := 0; i < leaves; i++ {
+		g.AddNode(Node{SteamID: fmt.Sprintf("leaf%d", i)})
+		g.AddEdge("centre", fmt.Sprintf("leaf%d", i))
+	}
+	return g
+}
+
+// newSyntheticGraph returns a random graph where each new node befriends
+// friendsPerNode existing nodes, preferring those with more friends
+func newSyntheticGraph(nodes, friendsPerNode int, seed int64) *Graph {
+	rng := rand.New(rand.NewSource(seed))
+	g := New()
+	endpoints := []int{}
+	for i := 0; i < nodes; i++ {
+		g.AddNode(Node{SteamID: fmt.Sprintf("%d", i)})
+		for k := 0; k < friendsPerNode && k < i; k++ {
+			target := rng.Intn(i)
+			if len(endpoints) > 0 && rng.Intn(2) == 0 {
+				target = endpoints[rng.Intn(len(endpoints))]
+			}
+			if g.AddEdge(fmt.Sprintf("%d", i), fmt.Sprintf("%d", target)) {
+				endpoints = append(endpoints, i, target)
+			}
+		}
+	}
+	return g
+}
+
+func TestCentralityOfStarGraph(t *testing.T) {
+	g := newStarGraph(4)
+
+	scores := g.Centrality(CentralityOptions{PageRank: DefaultPageRankOptions})
+
+	assert.Equal(t, 1.0, scores["centre"].Degree)
+	assert.Equal(t, 0.25, scores["leaf0"].Degree)
+	assert.Equal(t, 1.0, scores["centre"].Betweenness)
+	assert.Equal(t, 0.0, scores["leaf0"].Betweenness)
+	assert.Equal(t, 1.0, scores["centre"].Closeness)
+	assert.InDelta(t, 4.0/7.0, scores["leaf0"].Closeness, 1e-9)
+	assert.Greater(t, scores["centre"].PageRank, scores["leaf0"].PageRank)
+}
+
+func TestBetweennessCentralityOfCrawl(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	betweenness := g.BetweennessCentrality()
+
+	// root lies on every shortest path from carol, 3 of the 6 pairs
+	// not involving root
+	assert.InDelta(t, 0.5, betweenness["root"], 1e-9)
+	assert.Equal(t, 0.0, betweenness["carol"])
+	assert.Equal(t, betweenness["alice"], betweenness["bob"])
+}
+
+func TestApproximateBetweennessWithEverySourceIsExact(t *testing.T) {
+	g := newSyntheticGraph(50, 3, 1)
+
+	exact := g.BetweennessCentrality()
+	approximate := g.ApproximateBetweennessCentrality(50, 1)
+
+	for steamID, score := range exact {
+		assert.InDelta(t, score, approximate[steamID], 1e-9)
+	}
+}
+
+func TestApproximateBetweennessIsDeterministicForASeed(t *testing.T) {
+	g := newSyntheticGraph(200, 3, 1)
+
+	assert.Equal(t, g.ApproximateBetweennessCentrality(20, 5), g.ApproximateBetweennessCentrality(20, 5))
+}
+
+func TestClosenessCentralityOfDisconnectedGraph(t *testing.T) {
+	g := New()
+	for _, steamID := range []string{"a", "b", "c", "d"} {
+		g.AddNode(Node{SteamID: steamID})
+	}
+	g.AddEdge("a", "b")
+
+	closeness := g.ClosenessCentrality()
+
+	assert.InDelta(t, 1.0/3.0, closeness["a"], 1e-9)
+	assert.Equal(t, 0.0, closeness["c"])
+}
+
+func TestCentralityDefaultsZeroPageRankOptions(t *testing.T) {
+	g := newStarGraph(4)
+
+	scores := g.Centrality(CentralityOptions{})
+
+	expected := g.PageRank(DefaultPageRankOptions)
+	assert.Greater(t, scores["centre"].PageRank, scores["leaf0"].PageRank)
+	assert.Equal(t, expected["centre"], scores["centre"].PageRank)
+	assert.Equal(t, expected["leaf0"], scores["leaf0"].PageRank)
+}
+
+func TestPageRankSumsToOne(t *testing.T) {
+	g := newSyntheticGraph(100, 2, 1)
+	g.AddNode(Node{SteamID: "loner"})
+
+	pageRank := g.PageRank(DefaultPageRankOptions)
+
+	total := 0.0
+	for _, score := range pageRank {
+		total += score
+	}
+	assert.InDelta(t, 1.0, total, 1e-6)
+}
+
+func TestAttachCentrality(t *testing.T) {
+	data := newTestUsersGraphData()
+	g := FromUsersGraphData(data)
+
+	AttachCentrality(&data, g.Centrality(CentralityOptions{PageRank: DefaultPageRankOptions}))
+
+	assert.NotNil(t, data.UserDetails.Centrality)
+	assert.InDelta(t, 0.5, data.UserDetails.Centrality.Betweenness, 1e-9)
+	for _, friend := range data.FriendDetails {
+		assert.NotNil(t, friend.Centrality)
+	}
+}
+
+var (
+	benchmarkGraph     *Graph
+	benchmarkGraphOnce sync.Once
+)
+
+// getBenchmarkGraph returns a synthetic graph of 100k users
+// with an average of around 10 friends each
+func getBenchmarkGraph() *Graph {
+	benchmarkGraphOnce.Do(func() {
+		benchmarkGraph = newSyntheticGraph(100000, 5, 1)
+	})
+	return benchmarkGraph
+}
+
+func BenchmarkDegreeCentrality100k(b *testing.B) {
+	g := getBenchmarkGraph()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.DegreeCentrality()
+	}
+}
+
+func BenchmarkPageRank100k(b *testing.B) {
+	g := getBenchmarkGraph()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.PageRank(DefaultPageRankOptions)
+	}
+}
+
+func BenchmarkApproximateBetweennessCentrality100k(b *testing.B) {
+	g := getBenchmarkGraph()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.ApproximateBetweennessCentrality(64, 1)
+	}
+}
+
+// Exact betweenness and closeness run a search from every user so are
+// benchmarked on a smaller graph, both scale with nodes*edges
+func BenchmarkBetweennessCentrality5k(b *testing.B) {
+	g := newSyntheticGraph(5000, 5, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.BetweennessCentrality()
+	}
+}
+
+func BenchmarkClosenessCentrality5k(b *testing.B) {
+	g := newSyntheticGraph(5000, 5, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.ClosenessCentrality()
+	}
+}
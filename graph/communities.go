@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Communities is the result of community detection over a friend graph
+type Communities struct {
+	// Membership maps each steamID to a community ID. Communities are
+	// numbered from 0 in the order their first member appears in the graph
+	Membership map[string]int `json:"membership"`
+	Count      int            `json:"count"`
+	// Modularity is between -0.5 and 1, higher values mean
+	// more densely connected communities
+	Modularity float64 `json:"modularity"`
+}
+
+// weightedEdge is an edge in a graph being aggregated by Louvain
+type weightedEdge struct {
+	to     int
+	weight float64
+}
+
+// louvainGraph is a weighted graph where each node is a community of nodes
+// from the previous level. Edges within a community are dropped but still
+// count towards its degree
+type louvainGraph struct {
+	adj    [][]weightedEdge
+	degree []float64
+	total  float64
+}
+
+// Communities detects communities using the Louvain method. Nodes are visited
+// in an order shuffled by seed so the same seed always gives the same result
+func (g *Graph) Communities(seed int64) Communities {
+	rng := rand.New(rand.NewSource(seed))
+	membership := make([]int, len(g.nodes))
+	for i := range membership {
+		membership[i] = i
+	}
+
+	level := g.toLouvainGraph()
+	for {
+		moved, assignment := level.moveNodes(rng)
+		if !moved {
+			break
+		}
+		assignment, count := renumber(assignment)
+		for i := range membership {
+			membership[i] = assignment[membership[i]]
+		}
+		level = level.aggregate(assignment, count)
+	}
+
+	membership, count := renumber(membership)
+	communities := Communities{
+		Membership: make(map[string]int, len(g.nodes)),
+		Count:      count,
+		Modularity: g.modularity(membership, count),
+	}
+	for i, node := range g.nodes {
+		communities.Membership[node.SteamID] = membership[i]
+	}
+	return communities
+}
+
+func (g *Graph) toLouvainGraph() *louvainGraph {
+	lg := &louvainGraph{
+		adj:    make([][]weightedEdge, len(g.nodes)),
+		degree: make([]float64, len(g.nodes)),
+		total:  float64(g.edges),
+	}
+	for i, neighbours := range g.adj {
+		lg.adj[i] = make([]weightedEdge, len(neighbours))
+		for k, j := range neighbours {
+			lg.adj[i][k] = weightedEdge{to: j, weight: 1}
+		}
+		lg.degree[i] = float64(len(neighbours))
+	}
+	return lg
+}
+
+// moveNodes repeatedly moves each node into the neighbouring community that
+// most increases modularity until no move improves it, returning whether any
+// node changed community and each node's community
+func (lg *louvainGraph) moveNodes(rng *rand.Rand) (bool, []int) {
+	n := len(lg.adj)
+	community := make([]int, n)
+	communityTotal := make([]float64, n)
+	for i := range community {
+		community[i] = i
+		communityTotal[i] = lg.degree[i]
+	}
+	if lg.total == 0 {
+		return false, community
+	}
+
+	order := rng.Perm(n)
+	weightTo := make([]float64, n)
+	moved := false
+	for improved := true; improved; {
+		improved = false
+		for _, i := range order {
+			current := community[i]
+			communityTotal[current] -= lg.degree[i]
+
+			neighbourCommunities := []int{}
+			for _, edge := range lg.adj[i] {
+				c := community[edge.to]
+				if weightTo[c] == 0 {
+					neighbourCommunities = append(neighbourCommunities, c)
+				}
+				weightTo[c] += edge.weight
+			}
+			sort.Ints(neighbourCommunities)
+
+			best := current
+			bestGain := weightTo[current] - communityTotal[current]*lg.degree[i]/(2*lg.total)
+			for _, c := range neighbourCommunities {
+				gain := weightTo[c] - communityTotal[c]*lg.degree[i]/(2*lg.total)
+				if gain > bestGain+1e-12 {
+					best = c
+					bestGain = gain
+				}
+			}
+			for _, c := range neighbourCommunities {
+				weightTo[c] = 0
+			}
+
+			communityTotal[best] += lg.degree[i]
+			if best != current {
+				community[i] = best
+				improved = true
+				moved = true
+			}
+		}
+	}
+	return moved, community
+}
+
+// aggregate builds the next level graph where each node is a community
+func (lg *louvainGraph) aggregate(community []int, count int) *louvainGraph {
+	next := &louvainGraph{
+		adj:    make([][]weightedEdge, count),
+		degree: make([]float64, count),
+		total:  lg.total,
+	}
+	weights := make([]map[int]float64, count)
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	for i, edges := range lg.adj {
+		ci := community[i]
+		next.degree[ci] += lg.degree[i]
+		for _, edge := range edges {
+			if cj := community[edge.to]; ci != cj {
+				weights[ci][cj] += edge.weight
+			}
+		}
+	}
+	for c, neighbours := range weights {
+		for to, weight := range neighbours {
+			next.adj[c] = append(next.adj[c], weightedEdge{to: to, weight: weight})
+		}
+		sort.Slice(next.adj[c], func(a, b int) bool {
+			return next.adj[c][a].to < next.adj[c][b].to
+		})
+	}
+	return next
+}
+
+// renumber relabels communities from 0 in order of first appearance
+func renumber(community []int) ([]int, int) {
+	labels := make(map[int]int)
+	renumbered := make([]int, len(community))
+	for i, c := range community {
+		label, exists := labels[c]
+		if !exists {
+			label = len(labels)
+			labels[c] = label
+		}
+		renumbered[i] = label
+	}
+	return renumbered, len(labels)
+}
+
+// modularity measures how much more densely connected the communities
+// are than would be expected if edges were placed at random
+func (g *Graph) modularity(community []int, count int) float64 {
+	if g.edges == 0 {
+		return 0
+	}
+	internal := make([]float64, count)
+	total := make([]float64, count)
+	for i, neighbours := range g.adj {
+		total[community[i]] += float64(len(neighbours))
+		for _, j := range neighbours {
+			if community[i] == community[j] {
+				internal[community[i]]++
+			}
+		}
+	}
+	m := float64(g.edges)
+	q := 0.0
+	for c := 0; c < count; c++ {
+		// internal edges were counted from both ends
+		q += internal[c]/(2*m) - (total[c]/(2*m))*(total[c]/(2*m))
+	}
+	return q
+}
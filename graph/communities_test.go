@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCliquesGraph returns count cliques of size nodes each, with
+// consecutive cliques joined by a single edge
+func newCliquesGraph(count, size int) *Graph {
+	g := New()
+	for c := 0; c < count; c++ {
+		for i := 0; i < size; i++ {
+			g.AddNode(Node{SteamID: fmt.Sprintf("%d-%d", c, i)})
+		}
+		for i := 0; i < size; i++ {
+			for j := i + 1; j < size; j++ {
+				g.AddEdge(fmt.Sprintf("%d-%d", c, i), fmt.Sprintf("%d-%d", c, j))
+			}
+		}
+		if c > 0 {
+			g.AddEdge(fmt.Sprintf("%d-0", c-1), fmt.Sprintf("%d-%d", c, size-1))
+		}
+	}
+	return g
+}
+
+func TestCommunitiesFindsCliques(t *testing.T) {
+	g := newCliquesGraph(2, 4)
+
+	communities := g.Communities(1)
+
+	assert.Equal(t, 2, communities.Count)
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 0, communities.Membership[fmt.Sprintf("0-%d", i)])
+		assert.Equal(t, 1, communities.Membership[fmt.Sprintf("1-%d", i)])
+	}
+	// 2 * (6/13 - (13/26)^2)
+	assert.InDelta(t, 0.4231, communities.Modularity, 0.0001)
+}
+
+func TestCommunitiesIsDeterministicForASeed(t *testing.T) {
+	g := newCliquesGraph(6, 5)
+
+	first := g.Communities(42)
+	second := g.Communities(42)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 6, first.Count)
+}
+
+func TestCommunitiesOnCrawl(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	communities := g.Communities(7)
+
+	assert.Len(t, communities.Membership, 5)
+	assert.Equal(t, 0, communities.Membership["root"])
+	assert.GreaterOrEqual(t, communities.Modularity, 0.0)
+}
+
+func TestCommunitiesWithoutEdges(t *testing.T) {
+	g := New()
+	g.AddNode(Node{SteamID: "a"})
+	g.AddNode(Node{SteamID: "b"})
+
+	communities := g.Communities(1)
+
+	assert.Equal(t, 2, communities.Count)
+	assert.Equal(t, 0.0, communities.Modularity)
+	assert.Equal(t, map[string]int{"a": 0, "b": 1}, communities.Membership)
+}
@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// PersonaNameChange is a user that changed their name between crawls
+type PersonaNameChange struct {
+	SteamID string `json:"steamid"`
+	OldName string `json:"oldname"`
+	NewName string `json:"newname"`
+}
+
+// GameChange is a game that was added to a user's library or
+// played between crawls. Playtimes are in minutes
+type GameChange struct {
+	SteamID     string `json:"steamid"`
+	AppID       int    `json:"appid"`
+	OldPlaytime int    `json:"oldplaytime"`
+	NewPlaytime int    `json:"newplaytime"`
+	Delta       int    `json:"delta"`
+}
+
+// ChangeSet is how a user's friend network changed between two crawls
+type ChangeSet struct {
+	OriginalCrawlTarget string `json:"originalcrawltarget"`
+	// AddedFriends and RemovedFriends are changes to the
+	// crawl target's own friends list
+	AddedFriends   []string `json:"addedfriends"`
+	RemovedFriends []string `json:"removedfriends"`
+	// AddedUsers and RemovedUsers are changes to
+	// everyone found in the crawl
+	AddedUsers         []string            `json:"addedusers"`
+	RemovedUsers       []string            `json:"removedusers"`
+	PersonaNameChanges []PersonaNameChange `json:"personanamechanges"`
+	// NewGames are games owned by users in both crawls that
+	// were not in their library in the old crawl
+	NewGames []GameChange `json:"newgames"`
+	// PlaytimeChanges are games in both crawls that have been played since
+	PlaytimeChanges []GameChange `json:"playtimechanges"`
+}
+
+// Diff compares two crawls of the same user. Users found in both crawls are
+// compared in the order they appear in the later crawl
+func Diff(before, after common.UsersGraphData) (ChangeSet, error) {
+	oldTarget := before.UserDetails.User.AccDetails.SteamID
+	newTarget := after.UserDetails.User.AccDetails.SteamID
+	if oldTarget != newTarget {
+		return ChangeSet{}, fmt.Errorf("crawls are of different users: %s and %s", oldTarget, newTarget)
+	}
+
+	changes := ChangeSet{
+		OriginalCrawlTarget: newTarget,
+		PersonaNameChanges:  []PersonaNameChange{},
+		NewGames:            []GameChange{},
+		PlaytimeChanges:     []GameChange{},
+	}
+	changes.AddedFriends, changes.RemovedFriends = difference(before.UserDetails.User.FriendIDs, after.UserDetails.User.FriendIDs)
+
+	oldUsers := before.DistinctUsers()
+	newUsers := after.DistinctUsers()
+	oldIDs := make([]string, len(oldUsers))
+	oldByID := make(map[string]common.UserDocument, len(oldUsers))
+	for i, user := range oldUsers {
+		oldIDs[i] = user.AccDetails.SteamID
+		oldByID[user.AccDetails.SteamID] = user
+	}
+	newIDs := make([]string, len(newUsers))
+	for i, user := range newUsers {
+		newIDs[i] = user.AccDetails.SteamID
+	}
+	changes.AddedUsers, changes.RemovedUsers = difference(oldIDs, newIDs)
+
+	for _, newUser := range newUsers {
+		steamID := newUser.AccDetails.SteamID
+		oldUser, exists := oldByID[steamID]
+		if !exists {
+			continue
+		}
+		if oldUser.AccDetails.Personaname != newUser.AccDetails.Personaname {
+			changes.PersonaNameChanges = append(changes.PersonaNameChanges, PersonaNameChange{
+				SteamID: steamID,
+				OldName: oldUser.AccDetails.Personaname,
+				NewName: newUser.AccDetails.Personaname,
+			})
+		}
+
+		oldPlaytimes := make(map[int]int, len(oldUser.GamesOwned))
+		for _, game := range oldUser.GamesOwned {
+			oldPlaytimes[game.AppID] = game.Playtime_Forever
+		}
+		games := append([]common.GameOwnedDocument{}, newUser.GamesOwned...)
+		sort.Slice(games, func(i, j int) bool {
+			return games[i].AppID < games[j].AppID
+		})
+		for _, game := range games {
+			oldPlaytime, owned := oldPlaytimes[game.AppID]
+			change := GameChange{
+				SteamID:     steamID,
+				AppID:       game.AppID,
+				OldPlaytime: oldPlaytime,
+				NewPlaytime: game.Playtime_Forever,
+				Delta:       game.Playtime_Forever - oldPlaytime,
+			}
+			if !owned {
+				changes.NewGames = append(changes.NewGames, change)
+			} else if change.Delta != 0 {
+				changes.PlaytimeChanges = append(changes.PlaytimeChanges, change)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// difference returns the sorted steamIDs only in after and only in before
+func difference(before, after []string) ([]string, []string) {
+	inOld := make(map[string]bool, len(before))
+	for _, steamID := range before {
+		inOld[steamID] = true
+	}
+	inNew := make(map[string]bool, len(after))
+	for _, steamID := range after {
+		inNew[steamID] = true
+	}
+
+	added := []string{}
+	for steamID := range inNew {
+		if !inOld[steamID] {
+			added = append(added, steamID)
+		}
+	}
+	removed := []string{}
+	for steamID := range inOld {
+		if !inNew[steamID] {
+			removed = append(removed, steamID)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := newTestUsersGraphData()
+	before.FriendDetails[0].User.GamesOwned = []common.GameOwnedDocument{
+		{AppID: 10, Playtime_Forever: 100},
+		{AppID: 20, Playtime_Forever: 50},
+	}
+
+	after := newTestUsersGraphData()
+	after.UserDetails.User.FriendIDs = []string{"alice", "bob", "erin"}
+	after.FriendDetails[2] = newTestUser("erin", 1, "root", "root")
+	after.FriendDetails[1].User.AccDetails.Personaname = "bobby"
+	after.FriendDetails[0].User.GamesOwned = []common.GameOwnedDocument{
+		{AppID: 30, Playtime_Forever: 5},
+		{AppID: 20, Playtime_Forever: 50},
+		{AppID: 10, Playtime_Forever: 160},
+	}
+
+	changes, err := Diff(before, after)
+
+	assert.Nil(t, err)
+	assert.Equal(t, ChangeSet{
+		OriginalCrawlTarget: "root",
+		AddedFriends:        []string{"erin"},
+		RemovedFriends:      []string{"carol"},
+		AddedUsers:          []string{"erin"},
+		RemovedUsers:        []string{"carol"},
+		PersonaNameChanges: []PersonaNameChange{
+			{SteamID: "bob", OldName: "bobname", NewName: "bobby"},
+		},
+		NewGames: []GameChange{
+			{SteamID: "alice", AppID: 30, NewPlaytime: 5, Delta: 5},
+		},
+		PlaytimeChanges: []GameChange{
+			{SteamID: "alice", AppID: 10, OldPlaytime: 100, NewPlaytime: 160, Delta: 60},
+		},
+	}, changes)
+}
+
+func TestDiffOfIdenticalCrawlsIsEmpty(t *testing.T) {
+	changes, err := Diff(newTestUsersGraphData(), newTestUsersGraphData())
+
+	assert.Nil(t, err)
+	assert.Empty(t, changes.AddedFriends)
+	assert.Empty(t, changes.RemovedUsers)
+	assert.Empty(t, changes.PersonaNameChanges)
+	assert.Empty(t, changes.NewGames)
+	assert.Empty(t, changes.PlaytimeChanges)
+}
+
+func TestDiffRejectsCrawlsOfDifferentUsers(t *testing.T) {
+	other := newTestUsersGraphData()
+	other.UserDetails = newTestUser("alice", 0, "")
+
+	_, err := Diff(newTestUsersGraphData(), other)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// attribute is a node attribute written by every exporter
+type attribute struct {
+	name  string
+	kind  string
+	value func(Node) interface{}
+}
+
+// nodeAttributes are exported for every user, fromid and maxlevel are
+// included so that exported crawls can be imported again
+var nodeAttributes = []attribute{
+	{"personaname", "string", func(n Node) interface{} { return n.User.AccDetails.Personaname }},
+	{"loccountrycode", "string", func(n Node) interface{} { return n.User.AccDetails.Loccountrycode }},
+	{"gamesowned", "int", func(n Node) interface{} { return len(n.User.GamesOwned) }},
+	{"level", "int", func(n Node) interface{} { return n.Level }},
+	{"maxlevel", "int", func(n Node) interface{} { return n.MaxLevel }},
+	{"fromid", "string", func(n Node) interface{} { return n.FromID }},
+}
+
+// friendSinceAttribute is the only edge attribute, it is
+// only written for edges where it is known
+const friendSinceAttribute = "friendsince"
+
+// forEachEdge calls fn for every edge in node order
+func (g *Graph) forEachEdge(fn func(id int, source, target Node, friendSince int, hasFriendSince bool)) {
+	id := 0
+	for i, neighbours := range g.adj {
+		for _, j := range neighbours {
+			if i > j {
+				continue
+			}
+			since, exists := g.friendSince[edgeKey{i, j}]
+			fn(id, g.nodes[i], g.nodes[j], since, exists)
+			id++
+		}
+	}
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// WriteGraphML streams the graph to w in GraphML format
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, xml.Header)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, attr := range nodeAttributes {
+		fmt.Fprintf(bw, "  <key id=\"%s\" for=\"node\" attr.name=\"%s\" attr.type=\"%s\"/>\n", attr.name, attr.name, attr.kind)
+	}
+	fmt.Fprintf(bw, "  <key id=\"%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"long\"/>\n", friendSinceAttribute, friendSinceAttribute)
+	fmt.Fprintf(bw, "  <graph id=\"%s\" edgedefault=\"undirected\">\n", xmlEscape(g.root))
+
+	for _, node := range g.nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", xmlEscape(node.SteamID))
+		for _, attr := range nodeAttributes {
+			fmt.Fprintf(bw, "      <data key=\"%s\">%s</data>\n", attr.name, xmlEscape(fmt.Sprint(attr.value(node))))
+		}
+		fmt.Fprintln(bw, "    </node>")
+	}
+	g.forEachEdge(func(id int, source, target Node, friendSince int, hasFriendSince bool) {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\"", id, xmlEscape(source.SteamID), xmlEscape(target.SteamID))
+		if !hasFriendSince {
+			fmt.Fprintln(bw, "/>")
+			return
+		}
+		fmt.Fprintf(bw, ">\n      <data key=\"%s\">%d</data>\n    </edge>\n", friendSinceAttribute, friendSince)
+	})
+
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+// WriteGEXF streams the graph to w in GEXF 1.3 format, used by Gephi
+func (g *Graph) WriteGEXF(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, xml.Header)
+	fmt.Fprintln(bw, `<gexf xmlns="http://gexf.net/1.3" version="1.3">`)
+	fmt.Fprintln(bw, `  <graph defaultedgetype="undirected" mode="static">`)
+	fmt.Fprintln(bw, `    <attributes class="node">`)
+	for _, attr := range nodeAttributes {
+		kind := attr.kind
+		if kind == "int" {
+			kind = "integer"
+		}
+		fmt.Fprintf(bw, "      <attribute id=\"%s\" title=\"%s\" type=\"%s\"/>\n", attr.name, attr.name, kind)
+	}
+	fmt.Fprintln(bw, `    </attributes>`)
+	fmt.Fprintln(bw, `    <attributes class="edge">`)
+	fmt.Fprintf(bw, "      <attribute id=\"%s\" title=\"%s\" type=\"long\"/>\n", friendSinceAttribute, friendSinceAttribute)
+	fmt.Fprintln(bw, `    </attributes>`)
+
+	fmt.Fprintln(bw, "    <nodes>")
+	for _, node := range g.nodes {
+		fmt.Fprintf(bw, "      <node id=\"%s\" label=\"%s\">\n", xmlEscape(node.SteamID), xmlEscape(node.User.AccDetails.Personaname))
+		fmt.Fprintln(bw, "        <attvalues>")
+		for _, attr := range nodeAttributes {
+			fmt.Fprintf(bw, "          <attvalue for=\"%s\" value=\"%s\"/>\n", attr.name, xmlEscape(fmt.Sprint(attr.value(node))))
+		}
+		fmt.Fprintln(bw, "        </attvalues>")
+		fmt.Fprintln(bw, "      </node>")
+	}
+	fmt.Fprintln(bw, "    </nodes>")
+
+	fmt.Fprintln(bw, "    <edges>")
+	g.forEachEdge(func(id int, source, target Node, friendSince int, hasFriendSince bool) {
+		fmt.Fprintf(bw, "      <edge id=\"%d\" source=\"%s\" target=\"%s\"", id, xmlEscape(source.SteamID), xmlEscape(target.SteamID))
+		if !hasFriendSince {
+			fmt.Fprintln(bw, "/>")
+			return
+		}
+		fmt.Fprintln(bw, ">")
+		fmt.Fprintf(bw, "        <attvalues><attvalue for=\"%s\" value=\"%d\"/></attvalues>\n", friendSinceAttribute, friendSince)
+		fmt.Fprintln(bw, "      </edge>")
+	})
+	fmt.Fprintln(bw, "    </edges>")
+
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</gexf>")
+	return bw.Flush()
+}
+
+// dotQuote quotes s as a DOT string. Graphviz doesn't decode Go escapes
+// so only quotes and backslashes are escaped and UTF-8 is kept as is
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// WriteDOT streams the graph to w in Graphviz DOT format
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph friends {")
+	for _, node := range g.nodes {
+		fmt.Fprintf(bw, "  %s [label=%s", dotQuote(node.SteamID), dotQuote(node.User.AccDetails.Personaname))
+		for _, attr := range nodeAttributes {
+			value := attr.value(node)
+			if attr.kind == "string" {
+				value = dotQuote(value.(string))
+			}
+			fmt.Fprintf(bw, ", %s=%v", attr.name, value)
+		}
+		fmt.Fprintln(bw, "];")
+	}
+	g.forEachEdge(func(id int, source, target Node, friendSince int, hasFriendSince bool) {
+		fmt.Fprintf(bw, "  %s -- %s", dotQuote(source.SteamID), dotQuote(target.SteamID))
+		if hasFriendSince {
+			fmt.Fprintf(bw, " [%s=%d]", friendSinceAttribute, friendSince)
+		}
+		fmt.Fprintln(bw, ";")
+	})
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// cytoscapeElement is a single node or edge in Cytoscape.js JSON
+type cytoscapeElement struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// WriteCytoscapeJSON streams the graph to w in the Cytoscape.js elements
+// JSON format, writing one element at a time
+func (g *Graph) WriteCytoscapeJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	writeElement := func(first bool, element cytoscapeElement) error {
+		if !first {
+			bw.WriteString(",")
+		}
+		encoded, err := json.Marshal(element)
+		if err != nil {
+			return err
+		}
+		bw.Write(encoded)
+		return nil
+	}
+
+	bw.WriteString(`{"elements":{"nodes":[`)
+	for i, node := range g.nodes {
+		data := map[string]interface{}{"id": node.SteamID}
+		for _, attr := range nodeAttributes {
+			data[attr.name] = attr.value(node)
+		}
+		if err := writeElement(i == 0, cytoscapeElement{Data: data}); err != nil {
+			return err
+		}
+	}
+	bw.WriteString(`],"edges":[`)
+	var err error
+	g.forEachEdge(func(id int, source, target Node, friendSince int, hasFriendSince bool) {
+		if err != nil {
+			return
+		}
+		data := map[string]interface{}{
+			"id":     fmt.Sprintf("e%d", id),
+			"source": source.SteamID,
+			"target": target.SteamID,
+		}
+		if hasFriendSince {
+			data[friendSinceAttribute] = friendSince
+		}
+		err = writeElement(id == 0, cytoscapeElement{Data: data})
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("]}}\n")
+	return bw.Flush()
+}
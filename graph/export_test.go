@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestExportGraph() *Graph {
+	data := newTestUsersGraphData()
+	data.UserDetails.User.AccDetails.Personaname = `<"root" & co>`
+	data.UserDetails.User.AccDetails.Loccountrycode = "IE"
+	data.UserDetails.User.GamesOwned = []common.GameOwnedDocument{{AppID: 10}, {AppID: 20}}
+	g := FromUsersGraphData(data)
+	g.SetFriendSince("root", "alice", 1300000000)
+	return g
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, newTestExportGraph().WriteGraphML(&buf))
+
+	parsed := struct {
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []struct {
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}{}
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &parsed))
+	assert.Len(t, parsed.Graph.Nodes, 5)
+	assert.Len(t, parsed.Graph.Edges, 6)
+
+	rootData := map[string]string{}
+	for _, data := range parsed.Graph.Nodes[0].Data {
+		rootData[data.Key] = data.Value
+	}
+	assert.Equal(t, `<"root" & co>`, rootData["personaname"])
+	assert.Equal(t, "IE", rootData["loccountrycode"])
+	assert.Equal(t, "2", rootData["gamesowned"])
+	assert.Equal(t, "0", rootData["level"])
+
+	assert.Equal(t, "root", parsed.Graph.Edges[0].Source)
+	assert.Equal(t, "alice", parsed.Graph.Edges[0].Target)
+	assert.Equal(t, "1300000000", parsed.Graph.Edges[0].Data[0].Value)
+	assert.Empty(t, parsed.Graph.Edges[1].Data)
+}
+
+func TestWriteGEXF(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, newTestExportGraph().WriteGEXF(&buf))
+
+	parsed := struct {
+		Graph struct {
+			Nodes []struct {
+				ID    string `xml:"id,attr"`
+				Label string `xml:"label,attr"`
+			} `xml:"nodes>node"`
+			Edges []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"edges>edge"`
+		} `xml:"graph"`
+	}{}
+	assert.Nil(t, xml.Unmarshal(buf.Bytes(), &parsed))
+	assert.Len(t, parsed.Graph.Nodes, 5)
+	assert.Equal(t, `<"root" & co>`, parsed.Graph.Nodes[0].Label)
+	assert.Len(t, parsed.Graph.Edges, 6)
+	assert.Contains(t, buf.String(), `<attvalue for="friendsince" value="1300000000"/>`)
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, newTestExportGraph().WriteDOT(&buf))
+
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "graph friends {\n"))
+	assert.Contains(t, dot, `"root" [label="<\"root\" & co>", personaname="<\"root\" & co>", loccountrycode="IE", gamesowned=2, level=0, maxlevel=2, fromid=""];`)
+	assert.Contains(t, dot, `"root" -- "alice" [friendsince=1300000000];`)
+	assert.Contains(t, dot, `"alice" -- "bob";`)
+	assert.Equal(t, 6, strings.Count(dot, " -- "))
+}
+
+func TestWriteDOTKeepsUTF8(t *testing.T) {
+	g := New()
+	g.AddNode(Node{SteamID: "1", User: common.UserDocument{AccDetails: common.AccDetailsDocument{Personaname: `Zoë \ 日本`}}})
+	var buf bytes.Buffer
+	assert.Nil(t, g.WriteDOT(&buf))
+
+	assert.Contains(t, buf.String(), `"1" [label="Zoë \\ 日本"`)
+}
+
+func TestWriteCytoscapeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, newTestExportGraph().WriteCytoscapeJSON(&buf))
+
+	parsed := struct {
+		Elements struct {
+			Nodes []cytoscapeElement `json:"nodes"`
+			Edges []cytoscapeElement `json:"edges"`
+		} `json:"elements"`
+	}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &parsed))
+	assert.Len(t, parsed.Elements.Nodes, 5)
+	assert.Len(t, parsed.Elements.Edges, 6)
+	assert.Equal(t, "root", parsed.Elements.Nodes[0].Data["id"])
+	assert.Equal(t, float64(2), parsed.Elements.Nodes[0].Data["gamesowned"])
+	assert.Equal(t, float64(1300000000), parsed.Elements.Edges[0].Data["friendsince"])
+	assert.NotContains(t, parsed.Elements.Edges[1].Data, "friendsince")
+}
+
+func TestExportersReturnWriteErrors(t *testing.T) {
+	g := newTestExportGraph()
+	for _, write := range []func(io.Writer) error{g.WriteGraphML, g.WriteGEXF, g.WriteDOT, g.WriteCytoscapeJSON} {
+		assert.Error(t, write(failingWriter{}))
+	}
+}
+
+func TestExportEmptyGraph(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, New().WriteCytoscapeJSON(&buf))
+	assert.JSONEq(t, `{"elements":{"nodes":[],"edges":[]}}`, buf.String())
+}
@@ -0,0 +1,295 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// Node is a single user in the friend graph along
+// with where they were found during the crawl
+type Node struct {
+	SteamID  string
+	User     common.UserDocument
+	FromID   string
+	Level    int
+	MaxLevel int
+}
+
+// Graph is an undirected friend graph with nodes keyed by steamID. Nodes are
+// kept in insertion order and neighbours in node order so that iterating
+// over a graph, and any algorithm run over it, is deterministic
+type Graph struct {
+	root        string
+	nodes       []Node
+	index       map[string]int
+	adj         [][]int
+	edges       int
+	friendSince map[edgeKey]int
+}
+
+// edgeKey identifies an undirected edge by its node indexes, lowest first
+type edgeKey [2]int
+
+func newEdgeKey(i, j int) edgeKey {
+	if i > j {
+		i, j = j, i
+	}
+	return edgeKey{i, j}
+}
+
+// New creates an empty graph
+func New() *Graph {
+	return &Graph{
+		index:       make(map[string]int),
+		friendSince: make(map[edgeKey]int),
+	}
+}
+
+// FromUsersGraphData builds a friend graph from a crawl. Edges are added from
+// each user's FriendIDs and FromID, friends that were not crawled (and so are
+// not in the graph) are ignored. If a user appears more than once the
+// occurrence with the lowest level is kept
+func FromUsersGraphData(data common.UsersGraphData) *Graph {
+	g := New()
+	users := append([]common.UsersGraphInformation{data.UserDetails}, data.FriendDetails...)
+	for _, user := range users {
+		steamID := user.User.AccDetails.SteamID
+		if steamID == "" {
+			continue
+		}
+		if i, exists := g.index[steamID]; exists {
+			if user.CurrentLevel < g.nodes[i].Level {
+				g.nodes[i] = nodeFromUsersGraphInformation(user)
+			}
+			continue
+		}
+		g.AddNode(nodeFromUsersGraphInformation(user))
+	}
+	g.root = data.UserDetails.User.AccDetails.SteamID
+
+	for _, user := range users {
+		steamID := user.User.AccDetails.SteamID
+		for _, friendID := range user.User.FriendIDs {
+			g.AddEdge(steamID, friendID)
+		}
+		g.AddEdge(steamID, user.FromID)
+	}
+	return g
+}
+
+func nodeFromUsersGraphInformation(user common.UsersGraphInformation) Node {
+	return Node{
+		SteamID:  user.User.AccDetails.SteamID,
+		User:     user.User,
+		FromID:   user.FromID,
+		Level:    user.CurrentLevel,
+		MaxLevel: user.MaxLevel,
+	}
+}
+
+// AddNode adds a node to the graph, returning false if a
+// node with the same steamID already exists
+func (g *Graph) AddNode(node Node) bool {
+	if _, exists := g.index[node.SteamID]; exists {
+		return false
+	}
+	g.index[node.SteamID] = len(g.nodes)
+	g.nodes = append(g.nodes, node)
+	g.adj = append(g.adj, nil)
+	return true
+}
+
+// AddEdge adds an undirected edge between two existing nodes, returning
+// false if either node does not exist, they are the same node or
+// the edge already exists
+func (g *Graph) AddEdge(a, b string) bool {
+	i, okA := g.index[a]
+	j, okB := g.index[b]
+	if !okA || !okB || i == j {
+		return false
+	}
+	var inserted bool
+	g.adj[i], inserted = insertSorted(g.adj[i], j)
+	if !inserted {
+		return false
+	}
+	g.adj[j], _ = insertSorted(g.adj[j], i)
+	g.edges++
+	return true
+}
+
+// insertSorted inserts v into a sorted slice if it is not already present
+func insertSorted(s []int, v int) ([]int, bool) {
+	pos := sort.SearchInts(s, v)
+	if pos < len(s) && s[pos] == v {
+		return s, false
+	}
+	s = append(s, 0)
+	copy(s[pos+1:], s[pos:])
+	s[pos] = v
+	return s, true
+}
+
+// SetFriendSince records when two users became friends (a unix timestamp as
+// returned by the steam web API), returning false if they are not friends
+// in the graph
+func (g *Graph) SetFriendSince(a, b string, since int) bool {
+	if !g.HasEdge(a, b) {
+		return false
+	}
+	g.friendSince[newEdgeKey(g.index[a], g.index[b])] = since
+	return true
+}
+
+// SetFriendSinceFromFriendsList records when a user became friends with
+// each of the friends in their steam friends list
+func (g *Graph) SetFriendSinceFromFriendsList(steamID string, friends common.Friendslist) {
+	for _, friend := range friends.Friends {
+		g.SetFriendSince(steamID, friend.Steamid, friend.FriendSince)
+	}
+}
+
+// FriendSince returns when two users became friends, if known
+func (g *Graph) FriendSince(a, b string) (int, bool) {
+	i, okA := g.index[a]
+	j, okB := g.index[b]
+	if !okA || !okB {
+		return 0, false
+	}
+	since, exists := g.friendSince[newEdgeKey(i, j)]
+	return since, exists
+}
+
+// SetRoot marks the user that the crawl started from
+func (g *Graph) SetRoot(steamID string) {
+	g.root = steamID
+}
+
+// Root returns the user that the crawl started from
+func (g *Graph) Root() (Node, bool) {
+	return g.Node(g.root)
+}
+
+// Node returns the node for a given steamID
+func (g *Graph) Node(steamID string) (Node, bool) {
+	i, exists := g.index[steamID]
+	if !exists {
+		return Node{}, false
+	}
+	return g.nodes[i], true
+}
+
+// HasNode determines if a user is in the graph
+func (g *Graph) HasNode(steamID string) bool {
+	_, exists := g.index[steamID]
+	return exists
+}
+
+// HasEdge determines if two users are friends in the graph
+func (g *Graph) HasEdge(a, b string) bool {
+	i, okA := g.index[a]
+	j, okB := g.index[b]
+	if !okA || !okB {
+		return false
+	}
+	return g.hasEdgeByIndex(i, j)
+}
+
+func (g *Graph) hasEdgeByIndex(i, j int) bool {
+	pos := sort.SearchInts(g.adj[i], j)
+	return pos < len(g.adj[i]) && g.adj[i][pos] == j
+}
+
+// NodeCount returns the number of users in the graph
+func (g *Graph) NodeCount() int {
+	return len(g.nodes)
+}
+
+// EdgeCount returns the number of friendships in the graph
+func (g *Graph) EdgeCount() int {
+	return g.edges
+}
+
+// Nodes returns every node in insertion order
+func (g *Graph) Nodes() []Node {
+	nodes := make([]Node, len(g.nodes))
+	copy(nodes, g.nodes)
+	return nodes
+}
+
+// SteamIDs returns the steamID of every node in insertion order
+func (g *Graph) SteamIDs() []string {
+	steamIDs := make([]string, len(g.nodes))
+	for i, node := range g.nodes {
+		steamIDs[i] = node.SteamID
+	}
+	return steamIDs
+}
+
+// Neighbours returns the steamIDs of a user's friends in the graph
+func (g *Graph) Neighbours(steamID string) []string {
+	i, exists := g.index[steamID]
+	if !exists {
+		return nil
+	}
+	neighbours := make([]string, len(g.adj[i]))
+	for k, j := range g.adj[i] {
+		neighbours[k] = g.nodes[j].SteamID
+	}
+	return neighbours
+}
+
+// ForEachNeighbour calls fn for each of a user's friends in the
+// graph, stopping early if fn returns false
+func (g *Graph) ForEachNeighbour(steamID string, fn func(Node) bool) {
+	i, exists := g.index[steamID]
+	if !exists {
+		return
+	}
+	for _, j := range g.adj[i] {
+		if !fn(g.nodes[j]) {
+			return
+		}
+	}
+}
+
+// Degree returns the number of friends a user has in the graph
+func (g *Graph) Degree(steamID string) int {
+	i, exists := g.index[steamID]
+	if !exists {
+		return 0
+	}
+	return len(g.adj[i])
+}
+
+// Subgraph returns a new graph containing only the nodes for which
+// keep returns true and the edges between them
+func (g *Graph) Subgraph(keep func(Node) bool) *Graph {
+	sub := New()
+	for _, node := range g.nodes {
+		if keep(node) {
+			sub.AddNode(node)
+		}
+	}
+	for i, neighbours := range g.adj {
+		for _, j := range neighbours {
+			if i < j && sub.AddEdge(g.nodes[i].SteamID, g.nodes[j].SteamID) {
+				if since, exists := g.friendSince[edgeKey{i, j}]; exists {
+					sub.SetFriendSince(g.nodes[i].SteamID, g.nodes[j].SteamID, since)
+				}
+			}
+		}
+	}
+	if sub.HasNode(g.root) {
+		sub.root = g.root
+	}
+	return sub
+}
+
+// SubgraphByLevel returns the part of the graph crawled up to and including maxLevel
+func (g *Graph) SubgraphByLevel(maxLevel int) *Graph {
+	return g.Subgraph(func(node Node) bool {
+		return node.Level <= maxLevel
+	})
+}
@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUser(steamID string, level int, fromID string, friendIDs ...string) common.UsersGraphInformation {
+	return common.UsersGraphInformation{
+		User: common.UserDocument{
+			AccDetails: common.AccDetailsDocument{
+				SteamID:     steamID,
+				Personaname: steamID + "name",
+			},
+			FriendIDs: friendIDs,
+		},
+		FromID:       fromID,
+		MaxLevel:     2,
+		CurrentLevel: level,
+	}
+}
+
+// newTestUsersGraphData returns a small crawl:
+//
+//	root - alice - dave
+//	  | \   |    /
+//	  |   bob --
+//	carol
+func newTestUsersGraphData() common.UsersGraphData {
+	return common.UsersGraphData{
+		UserDetails: newTestUser("root", 0, "", "alice", "bob", "carol"),
+		FriendDetails: []common.UsersGraphInformation{
+			newTestUser("alice", 1, "root", "root", "bob", "dave"),
+			newTestUser("bob", 1, "root", "root", "alice"),
+			newTestUser("carol", 1, "root", "root", "erin"),
+			newTestUser("dave", 2, "alice", "alice"),
+			newTestUser("dave", 2, "bob", "alice"),
+		},
+	}
+}
+
+func TestFromUsersGraphDataBuildsNodesAndEdges(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	assert.Equal(t, 5, g.NodeCount())
+	assert.Equal(t, 6, g.EdgeCount())
+	assert.Equal(t, []string{"root", "alice", "bob", "carol", "dave"}, g.SteamIDs())
+	assert.True(t, g.HasEdge("bob", "dave"), "FromID should add an edge")
+	assert.True(t, g.HasEdge("dave", "alice"))
+	assert.False(t, g.HasNode("erin"), "uncrawled friends should not be added")
+
+	root, ok := g.Root()
+	assert.True(t, ok)
+	assert.Equal(t, "root", root.SteamID)
+	dave, _ := g.Node("dave")
+	assert.Equal(t, 2, dave.Level)
+	assert.Equal(t, "alice", dave.FromID)
+}
+
+func TestFromUsersGraphDataKeepsLowestLevelDuplicate(t *testing.T) {
+	data := newTestUsersGraphData()
+	data.FriendDetails = append(data.FriendDetails, newTestUser("carol", 0, "root"))
+	data.FriendDetails[2].CurrentLevel = 2
+
+	g := FromUsersGraphData(data)
+	carol, _ := g.Node("carol")
+
+	assert.Equal(t, 0, carol.Level)
+}
+
+func TestNeighboursAndDegree(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	assert.Equal(t, []string{"root", "bob", "dave"}, g.Neighbours("alice"))
+	assert.Equal(t, 3, g.Degree("alice"))
+	assert.Equal(t, 1, g.Degree("carol"))
+	assert.Equal(t, 0, g.Degree("erin"))
+	assert.Nil(t, g.Neighbours("erin"))
+}
+
+func TestForEachNeighbourStopsEarly(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	visited := []string{}
+	g.ForEachNeighbour("root", func(node Node) bool {
+		visited = append(visited, node.SteamID)
+		return len(visited) < 2
+	})
+
+	assert.Equal(t, []string{"alice", "bob"}, visited)
+}
+
+func TestAddEdgeRejectsDuplicatesAndSelfLoops(t *testing.T) {
+	g := New()
+	g.AddNode(Node{SteamID: "a"})
+	g.AddNode(Node{SteamID: "b"})
+
+	assert.False(t, g.AddNode(Node{SteamID: "a"}))
+	assert.True(t, g.AddEdge("a", "b"))
+	assert.False(t, g.AddEdge("b", "a"))
+	assert.False(t, g.AddEdge("a", "a"))
+	assert.False(t, g.AddEdge("a", "c"))
+	assert.Equal(t, 1, g.EdgeCount())
+}
+
+func TestSubgraphByLevel(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	sub := g.SubgraphByLevel(1)
+
+	assert.Equal(t, []string{"root", "alice", "bob", "carol"}, sub.SteamIDs())
+	assert.Equal(t, 4, sub.EdgeCount())
+	assert.False(t, sub.HasNode("dave"))
+	_, hasRoot := sub.Root()
+	assert.True(t, hasRoot)
+}
+
+func TestFriendSince(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+	g.SetFriendSinceFromFriendsList("root", common.Friendslist{
+		Friends: []common.Friend{
+			{Steamid: "alice", FriendSince: 1300000000},
+			{Steamid: "erin", FriendSince: 1400000000},
+		},
+	})
+
+	since, exists := g.FriendSince("alice", "root")
+	assert.True(t, exists)
+	assert.Equal(t, 1300000000, since)
+	_, exists = g.FriendSince("root", "bob")
+	assert.False(t, exists)
+	assert.False(t, g.SetFriendSince("root", "dave", 1))
+
+	sub := g.SubgraphByLevel(1)
+	since, exists = sub.FriendSince("root", "alice")
+	assert.True(t, exists)
+	assert.Equal(t, 1300000000, since)
+}
@@ -0,0 +1,256 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// DanglingReference is a reference to a user that is not in the crawl
+type DanglingReference struct {
+	// SteamID is the user holding the reference
+	SteamID string `json:"steamid"`
+	// Field is where the reference was found, either
+	// "friendids", "fromid" or "edge"
+	Field   string `json:"field"`
+	Missing string `json:"missing"`
+}
+
+// ValidateReferences reports every FriendIDs and FromID entry in a crawl that
+// refers to a user not in the crawl. Friends of users on the final level of a
+// crawl were never crawled themselves so are expected to be reported
+func ValidateReferences(data common.UsersGraphData) []DanglingReference {
+	users := append([]common.UsersGraphInformation{data.UserDetails}, data.FriendDetails...)
+	known := make(map[string]bool, len(users))
+	for _, user := range users {
+		known[user.User.AccDetails.SteamID] = true
+	}
+
+	dangling := []DanglingReference{}
+	for _, user := range users {
+		steamID := user.User.AccDetails.SteamID
+		for _, friendID := range user.User.FriendIDs {
+			if !known[friendID] {
+				dangling = append(dangling, DanglingReference{SteamID: steamID, Field: "friendids", Missing: friendID})
+			}
+		}
+		if user.FromID != "" && !known[user.FromID] {
+			dangling = append(dangling, DanglingReference{SteamID: steamID, Field: "fromid", Missing: user.FromID})
+		}
+	}
+	return dangling
+}
+
+// importedNode is a node read from an exported file
+// with its attributes as strings
+type importedNode struct {
+	id    string
+	attrs map[string]string
+}
+
+// importedEdge is an edge read from an exported file
+type importedEdge struct {
+	source string
+	target string
+}
+
+// ReadGraphML reads a friend graph written by WriteGraphML back into a crawl.
+// Users are restored with their account details, friends, level and where
+// they were found but not the games they own, which are only exported as a
+// count. Edges to users missing from the file are returned as dangling
+// references along with any dangling FromIDs
+func ReadGraphML(r io.Reader) (common.UsersGraphData, []DanglingReference, error) {
+	decoder := xml.NewDecoder(r)
+	keyNames := make(map[string]string)
+	nodes := []importedNode{}
+	edges := []importedEdge{}
+	root := ""
+
+	var currentNode *importedNode
+	currentKey := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return common.UsersGraphData{}, nil, fmt.Errorf("could not parse GraphML: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string)
+			for _, attr := range element.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			switch element.Name.Local {
+			case "key":
+				keyNames[attrs["id"]] = attrs["attr.name"]
+			case "graph":
+				root = attrs["id"]
+			case "node":
+				nodes = append(nodes, importedNode{id: attrs["id"], attrs: make(map[string]string)})
+				currentNode = &nodes[len(nodes)-1]
+			case "edge":
+				edges = append(edges, importedEdge{source: attrs["source"], target: attrs["target"]})
+			case "data":
+				currentKey = attrs["key"]
+			}
+		case xml.CharData:
+			if currentNode != nil && currentKey != "" {
+				name := currentKey
+				if keyName, exists := keyNames[currentKey]; exists {
+					name = keyName
+				}
+				currentNode.attrs[name] += string(element)
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "node":
+				currentNode = nil
+			case "data":
+				currentKey = ""
+			}
+		}
+	}
+	return buildImportedUsersGraphData(root, nodes, edges)
+}
+
+// ReadCytoscapeJSON reads a friend graph written by WriteCytoscapeJSON back
+// into a crawl, see ReadGraphML for what is restored
+func ReadCytoscapeJSON(r io.Reader) (common.UsersGraphData, []DanglingReference, error) {
+	document := struct {
+		Elements struct {
+			Nodes []cytoscapeElement `json:"nodes"`
+			Edges []cytoscapeElement `json:"edges"`
+		} `json:"elements"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return common.UsersGraphData{}, nil, fmt.Errorf("could not parse Cytoscape JSON: %w", err)
+	}
+
+	nodes := make([]importedNode, len(document.Elements.Nodes))
+	for i, element := range document.Elements.Nodes {
+		nodes[i] = importedNode{attrs: make(map[string]string)}
+		for key, value := range element.Data {
+			if key == "id" {
+				nodes[i].id = fmt.Sprint(value)
+				continue
+			}
+			nodes[i].attrs[key] = fmt.Sprint(value)
+		}
+	}
+	edges := make([]importedEdge, len(document.Elements.Edges))
+	for i, element := range document.Elements.Edges {
+		edges[i] = importedEdge{
+			source: fmt.Sprint(element.Data["source"]),
+			target: fmt.Sprint(element.Data["target"]),
+		}
+	}
+	return buildImportedUsersGraphData("", nodes, edges)
+}
+
+// buildImportedUsersGraphData turns imported nodes and edges into a crawl. The
+// root is the user with the given steamID if present, otherwise the first
+// user with the lowest level
+func buildImportedUsersGraphData(root string, nodes []importedNode, edges []importedEdge) (common.UsersGraphData, []DanglingReference, error) {
+	if len(nodes) == 0 {
+		return common.UsersGraphData{}, nil, errors.New("no users found to import")
+	}
+
+	users := make([]common.UsersGraphInformation, len(nodes))
+	index := make(map[string]int, len(nodes))
+	rootIndex := -1
+	for i, node := range nodes {
+		if node.id == "" {
+			return common.UsersGraphData{}, nil, fmt.Errorf("user %d has no id", i)
+		}
+		if _, exists := index[node.id]; exists {
+			return common.UsersGraphData{}, nil, fmt.Errorf("user %s appears more than once", node.id)
+		}
+		index[node.id] = i
+
+		user, err := importedNodeToUser(node)
+		if err != nil {
+			return common.UsersGraphData{}, nil, err
+		}
+		users[i] = user
+		if node.id == root {
+			rootIndex = i
+		}
+	}
+	if rootIndex == -1 {
+		rootIndex = 0
+		for i, user := range users {
+			if user.CurrentLevel < users[rootIndex].CurrentLevel {
+				rootIndex = i
+			}
+		}
+	}
+
+	dangling := []DanglingReference{}
+	for _, edge := range edges {
+		source, sourceExists := index[edge.source]
+		target, targetExists := index[edge.target]
+		if !sourceExists || !targetExists {
+			dangling = append(dangling, danglingEdge(edge, sourceExists))
+			continue
+		}
+		users[source].User.FriendIDs = append(users[source].User.FriendIDs, edge.target)
+		users[target].User.FriendIDs = append(users[target].User.FriendIDs, edge.source)
+	}
+
+	data := common.UsersGraphData{
+		UserDetails:   users[rootIndex],
+		FriendDetails: make([]common.UsersGraphInformation, 0, len(users)-1),
+	}
+	for i, user := range users {
+		if i != rootIndex {
+			data.FriendDetails = append(data.FriendDetails, user)
+		}
+	}
+	for _, reference := range ValidateReferences(data) {
+		if reference.Field == "fromid" {
+			dangling = append(dangling, reference)
+		}
+	}
+	return data, dangling, nil
+}
+
+func danglingEdge(edge importedEdge, sourceExists bool) DanglingReference {
+	if sourceExists {
+		return DanglingReference{SteamID: edge.source, Field: "edge", Missing: edge.target}
+	}
+	return DanglingReference{SteamID: edge.target, Field: "edge", Missing: edge.source}
+}
+
+func importedNodeToUser(node importedNode) (common.UsersGraphInformation, error) {
+	user := common.UsersGraphInformation{
+		User: common.UserDocument{
+			AccDetails: common.AccDetailsDocument{
+				SteamID:        node.id,
+				Personaname:    node.attrs["personaname"],
+				Loccountrycode: node.attrs["loccountrycode"],
+			},
+			FriendIDs: []string{},
+		},
+		FromID: node.attrs["fromid"],
+	}
+	for attr, dest := range map[string]*int{"level": &user.CurrentLevel, "maxlevel": &user.MaxLevel} {
+		value, exists := node.attrs[attr]
+		if !exists || value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return common.UsersGraphInformation{}, fmt.Errorf("user %s has an invalid %s: %q", node.id, attr, value)
+		}
+		*dest = parsed
+	}
+	return user, nil
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertSameGraph(t *testing.T, expected, actual *Graph) {
+	assert.Equal(t, expected.SteamIDs(), actual.SteamIDs())
+	assert.Equal(t, expected.EdgeCount(), actual.EdgeCount())
+	for _, steamID := range expected.SteamIDs() {
+		assert.Equal(t, expected.Neighbours(steamID), actual.Neighbours(steamID))
+		expectedNode, _ := expected.Node(steamID)
+		actualNode, _ := actual.Node(steamID)
+		assert.Equal(t, expectedNode.Level, actualNode.Level)
+		assert.Equal(t, expectedNode.MaxLevel, actualNode.MaxLevel)
+		assert.Equal(t, expectedNode.FromID, actualNode.FromID)
+		assert.Equal(t, expectedNode.User.AccDetails, actualNode.User.AccDetails)
+	}
+}
+
+func TestImportRoundTrips(t *testing.T) {
+	g := newTestExportGraph()
+	formats := map[string]struct {
+		write func(io.Writer) error
+		read  func(io.Reader) (common.UsersGraphData, []DanglingReference, error)
+	}{
+		"graphml":   {g.WriteGraphML, ReadGraphML},
+		"cytoscape": {g.WriteCytoscapeJSON, ReadCytoscapeJSON},
+	}
+
+	for name, format := range formats {
+		var buf bytes.Buffer
+		assert.Nil(t, format.write(&buf), name)
+
+		data, dangling, err := format.read(&buf)
+
+		assert.Nil(t, err, name)
+		assert.Empty(t, dangling, name)
+		assert.Equal(t, "root", data.UserDetails.User.AccDetails.SteamID, name)
+		assert.Len(t, data.FriendDetails, 4, name)
+		assertSameGraph(t, g, FromUsersGraphData(data))
+	}
+}
+
+func TestReadGraphMLReportsDanglingReferences(t *testing.T) {
+	graphML := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="level" attr.type="int"/>
+  <key id="d1" for="node" attr.name="fromid" attr.type="string"/>
+  <graph edgedefault="undirected">
+    <node id="bob"><data key="d0">1</data><data key="d1">root</data></node>
+    <node id="alice"><data key="d0">0</data></node>
+    <edge source="alice" target="bob"/>
+    <edge source="alice" target="erin"/>
+  </graph>
+</graphml>`
+
+	data, dangling, err := ReadGraphML(strings.NewReader(graphML))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", data.UserDetails.User.AccDetails.SteamID, "lowest level user should be the root")
+	assert.Equal(t, []string{"bob"}, data.UserDetails.User.FriendIDs)
+	assert.Equal(t, []DanglingReference{
+		{SteamID: "alice", Field: "edge", Missing: "erin"},
+		{SteamID: "bob", Field: "fromid", Missing: "root"},
+	}, dangling)
+}
+
+func TestReadGraphMLRejectsInvalidInput(t *testing.T) {
+	_, _, err := ReadGraphML(strings.NewReader(`<graphml><graph><node id="a"><data key="level">one</data></node></graph></graphml>`))
+	assert.Contains(t, err.Error(), "invalid level")
+
+	_, _, err = ReadGraphML(strings.NewReader(`<graphml><graph></graph></graphml>`))
+	assert.Error(t, err)
+
+	_, _, err = ReadGraphML(strings.NewReader(`<graphml><graph>`))
+	assert.Error(t, err)
+}
+
+func TestReadCytoscapeJSONRejectsDuplicateUsers(t *testing.T) {
+	_, _, err := ReadCytoscapeJSON(strings.NewReader(`{"elements":{"nodes":[{"data":{"id":"a"}},{"data":{"id":"a"}}],"edges":[]}}`))
+
+	assert.Contains(t, err.Error(), "appears more than once")
+}
+
+func TestValidateReferences(t *testing.T) {
+	data := newTestUsersGraphData()
+	data.FriendDetails = append(data.FriendDetails, newTestUser("frank", 2, "gary"))
+
+	dangling := ValidateReferences(data)
+
+	assert.Equal(t, []DanglingReference{
+		{SteamID: "carol", Field: "friendids", Missing: "erin"},
+		{SteamID: "frank", Field: "fromid", Missing: "gary"},
+	}, dangling)
+}
@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Position is where a user is drawn in the graph view
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// LayoutOptions configures Layout, fields other than Seed and
+// Concentric left at 0 are taken from DefaultLayoutOptions
+type LayoutOptions struct {
+	Iterations int
+	// Seed chooses the starting positions, the same seed
+	// always gives the same layout
+	Seed int64
+	// Width and Height are the size of the area nodes are placed in,
+	// centred on the origin
+	Width  float64
+	Height float64
+	// Concentric places the crawl target at the origin and every
+	// other user on a ring RingSpacing * their level from it
+	Concentric  bool
+	RingSpacing float64
+}
+
+// DefaultLayoutOptions are a reasonable starting point for crawls
+// of up to a few thousand users
+var DefaultLayoutOptions = LayoutOptions{
+	Iterations:  100,
+	Seed:        1,
+	Width:       1000,
+	Height:      1000,
+	RingSpacing: 200,
+}
+
+// withDefaults returns opts with its unset fields
+// taken from DefaultLayoutOptions
+func (opts LayoutOptions) withDefaults() LayoutOptions {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultLayoutOptions.Iterations
+	}
+	if opts.Width <= 0 {
+		opts.Width = DefaultLayoutOptions.Width
+	}
+	if opts.Height <= 0 {
+		opts.Height = DefaultLayoutOptions.Height
+	}
+	if opts.RingSpacing <= 0 {
+		opts.RingSpacing = DefaultLayoutOptions.RingSpacing
+	}
+	return opts
+}
+
+// gridCell is a square of the layout area used to find nearby nodes
+type gridCell struct {
+	x, y int
+}
+
+// Layout computes a force-directed layout using Fruchterman-Reingold. Friends
+// attract each other and every pair of nearby users repels, only users within
+// twice the ideal edge length are considered (the grid variant) so each
+// iteration is close to linear in the size of the graph
+func (g *Graph) Layout(opts LayoutOptions) map[string]Position {
+	opts = opts.withDefaults()
+	n := len(g.nodes)
+	positions := make([]Position, n)
+	if n == 0 {
+		return map[string]Position{}
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	rootIndex, hasRoot := g.index[g.root]
+
+	ringRadius := func(i int) float64 {
+		return float64(g.nodes[i].Level) * opts.RingSpacing
+	}
+	for i := range positions {
+		if opts.Concentric {
+			angle := rng.Float64() * 2 * math.Pi
+			positions[i] = Position{X: ringRadius(i) * math.Cos(angle), Y: ringRadius(i) * math.Sin(angle)}
+			continue
+		}
+		positions[i] = Position{X: (rng.Float64() - 0.5) * opts.Width, Y: (rng.Float64() - 0.5) * opts.Height}
+	}
+
+	k := math.Sqrt(opts.Width * opts.Height / float64(n))
+	cellSize := 2 * k
+	displacement := make([]Position, n)
+	for iteration := 0; iteration < opts.Iterations; iteration++ {
+		temperature := opts.Width / 10 * (1 - float64(iteration)/float64(opts.Iterations))
+		for i := range displacement {
+			displacement[i] = Position{}
+		}
+
+		grid := make(map[gridCell][]int)
+		cellOf := func(p Position) gridCell {
+			return gridCell{int(math.Floor(p.X / cellSize)), int(math.Floor(p.Y / cellSize))}
+		}
+		for i, p := range positions {
+			cell := cellOf(p)
+			grid[cell] = append(grid[cell], i)
+		}
+
+		for i, p := range positions {
+			cell := cellOf(p)
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					for _, j := range grid[gridCell{cell.x + dx, cell.y + dy}] {
+						if i == j {
+							continue
+						}
+						deltaX, deltaY, distance := separation(p, positions[j], i, j)
+						if distance > cellSize {
+							continue
+						}
+						force := k * k / distance
+						displacement[i].X += deltaX / distance * force
+						displacement[i].Y += deltaY / distance * force
+					}
+				}
+			}
+		}
+
+		for i, neighbours := range g.adj {
+			for _, j := range neighbours {
+				if i > j {
+					continue
+				}
+				deltaX, deltaY, distance := separation(positions[i], positions[j], i, j)
+				force := distance * distance / k
+				displacement[i].X -= deltaX / distance * force
+				displacement[i].Y -= deltaY / distance * force
+				displacement[j].X += deltaX / distance * force
+				displacement[j].Y += deltaY / distance * force
+			}
+		}
+
+		for i := range positions {
+			length := math.Hypot(displacement[i].X, displacement[i].Y)
+			if length > 0 {
+				step := math.Min(length, temperature)
+				positions[i].X += displacement[i].X / length * step
+				positions[i].Y += displacement[i].Y / length * step
+			}
+
+			if opts.Concentric {
+				// Keep the angle the forces chose but move back onto the ring
+				radius := ringRadius(i)
+				distance := math.Hypot(positions[i].X, positions[i].Y)
+				if distance == 0 {
+					positions[i] = Position{X: radius}
+				} else {
+					positions[i].X *= radius / distance
+					positions[i].Y *= radius / distance
+				}
+				continue
+			}
+			positions[i].X = math.Max(-opts.Width/2, math.Min(opts.Width/2, positions[i].X))
+			positions[i].Y = math.Max(-opts.Height/2, math.Min(opts.Height/2, positions[i].Y))
+		}
+		if opts.Concentric && hasRoot {
+			positions[rootIndex] = Position{}
+		}
+	}
+
+	byID := make(map[string]Position, n)
+	for i, node := range g.nodes {
+		byID[node.SteamID] = positions[i]
+	}
+	return byID
+}
+
+// separation returns the vector from b to a and its length. Nodes in the
+// same place are pushed apart in a direction based on their indexes so
+// that layouts stay deterministic
+func separation(a, b Position, i, j int) (float64, float64, float64) {
+	deltaX, deltaY := a.X-b.X, a.Y-b.Y
+	distance := math.Hypot(deltaX, deltaY)
+	if distance < 1e-9 {
+		angle := float64(i-j) * 0.5
+		deltaX, deltaY = math.Cos(angle)*1e-3, math.Sin(angle)*1e-3
+		distance = 1e-3
+	}
+	return deltaX, deltaY, distance
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func distance(a, b Position) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+func TestLayoutIsDeterministicForASeed(t *testing.T) {
+	g := newCliquesGraph(3, 5)
+
+	assert.Equal(t, g.Layout(DefaultLayoutOptions), g.Layout(DefaultLayoutOptions))
+}
+
+func TestLayoutKeepsNodesInBounds(t *testing.T) {
+	g := newSyntheticGraph(300, 3, 1)
+
+	positions := g.Layout(DefaultLayoutOptions)
+
+	assert.Len(t, positions, 300)
+	for _, position := range positions {
+		assert.LessOrEqual(t, math.Abs(position.X), DefaultLayoutOptions.Width/2)
+		assert.LessOrEqual(t, math.Abs(position.Y), DefaultLayoutOptions.Height/2)
+	}
+}
+
+func TestLayoutGroupsCliquesTogether(t *testing.T) {
+	g := newCliquesGraph(2, 6)
+
+	positions := g.Layout(DefaultLayoutOptions)
+
+	within, between := 0.0, 0.0
+	for i := 1; i < 6; i++ {
+		within += distance(positions["0-0"], positions[fmt.Sprintf("0-%d", i)])
+		between += distance(positions["0-0"], positions[fmt.Sprintf("1-%d", i)])
+	}
+	assert.Less(t, within, between)
+}
+
+func TestLayoutConcentricRings(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+	opts := DefaultLayoutOptions
+	opts.Concentric = true
+
+	positions := g.Layout(opts)
+
+	assert.Equal(t, Position{}, positions["root"])
+	for _, node := range g.Nodes() {
+		assert.InDelta(t, float64(node.Level)*opts.RingSpacing, distance(Position{}, positions[node.SteamID]), 1e-6)
+	}
+}
+
+func TestLayoutDefaultsZeroOptions(t *testing.T) {
+	g := newCliquesGraph(3, 5)
+
+	positions := g.Layout(LayoutOptions{Seed: DefaultLayoutOptions.Seed})
+
+	assert.Equal(t, g.Layout(DefaultLayoutOptions), positions)
+	for _, position := range g.Layout(LayoutOptions{}) {
+		assert.False(t, math.IsNaN(position.X) || math.IsNaN(position.Y))
+		assert.LessOrEqual(t, math.Abs(position.X), DefaultLayoutOptions.Width/2)
+		assert.LessOrEqual(t, math.Abs(position.Y), DefaultLayoutOptions.Height/2)
+	}
+}
+
+func TestLayoutOfEmptyGraph(t *testing.T) {
+	assert.Empty(t, New().Layout(DefaultLayoutOptions))
+}
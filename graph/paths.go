@@ -0,0 +1,258 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoPath is returned when two users are not connected in the graph
+var ErrNoPath = errors.New("users are not connected")
+
+// PathOptions limits how many paths are enumerated
+type PathOptions struct {
+	// MaxShortestPaths is the maximum number of shortest paths returned
+	MaxShortestPaths int
+	// MaxAlternativePaths is the maximum number of longer paths returned
+	MaxAlternativePaths int
+	// MaxExtraHops is how much longer than the shortest path an
+	// alternative path can be
+	MaxExtraHops int
+}
+
+// DefaultPathOptions are sensible limits for displaying paths in the frontend
+var DefaultPathOptions = PathOptions{
+	MaxShortestPaths:    10,
+	MaxAlternativePaths: 10,
+	MaxExtraHops:        1,
+}
+
+// PathResult describes how two users are connected. Each path is
+// a list of steamIDs starting with from and ending with to
+type PathResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Degrees is the degrees of separation, the number of hops
+	// in the shortest path
+	Degrees int `json:"degrees"`
+	// ShortestPathCount is the total number of distinct shortest paths,
+	// which can be more than are returned in ShortestPaths. It is capped
+	// at math.MaxInt64
+	ShortestPathCount int64      `json:"shortestpathcount"`
+	ShortestPaths     [][]string `json:"shortestpaths"`
+	// AlternativePaths are simple paths longer than the shortest
+	// path, ordered by length
+	AlternativePaths [][]string `json:"alternativepaths"`
+}
+
+// ShortestPaths finds how two users are connected using a bidirectional breadth
+// first search, counting every shortest path and enumerating up to the limits
+// in opts. ErrNoPath is returned if the users are not connected
+func (g *Graph) ShortestPaths(from, to string, opts PathOptions) (PathResult, error) {
+	source, okFrom := g.index[from]
+	target, okTo := g.index[to]
+	if !okFrom || !okTo {
+		return PathResult{}, fmt.Errorf("both %q and %q must be in the graph", from, to)
+	}
+	result := PathResult{
+		From:             from,
+		To:               to,
+		ShortestPaths:    [][]string{},
+		AlternativePaths: [][]string{},
+	}
+	if source == target {
+		result.ShortestPathCount = 1
+		result.ShortestPaths = append(result.ShortestPaths, []string{from})
+		return result, nil
+	}
+
+	distFrom, countFrom, distTo, countTo, meeting := g.bidirectionalSearch(source, target)
+	if len(meeting) == 0 {
+		return PathResult{}, ErrNoPath
+	}
+	result.Degrees = distFrom[meeting[0]] + distTo[meeting[0]]
+	for _, v := range meeting {
+		result.ShortestPathCount = saturatingAdd(result.ShortestPathCount, saturatingMul(countFrom[v], countTo[v]))
+	}
+
+	for _, v := range meeting {
+		if len(result.ShortestPaths) >= opts.MaxShortestPaths {
+			break
+		}
+		g.enumerateHalfPaths(v, distFrom, func(head []int) bool {
+			g.enumerateHalfPaths(v, distTo, func(tail []int) bool {
+				path := make([]string, 0, result.Degrees+1)
+				for i := len(head) - 1; i >= 0; i-- {
+					path = append(path, g.nodes[head[i]].SteamID)
+				}
+				for _, u := range tail[1:] {
+					path = append(path, g.nodes[u].SteamID)
+				}
+				result.ShortestPaths = append(result.ShortestPaths, path)
+				return len(result.ShortestPaths) < opts.MaxShortestPaths
+			})
+			return len(result.ShortestPaths) < opts.MaxShortestPaths
+		})
+	}
+
+	if opts.MaxAlternativePaths > 0 && opts.MaxExtraHops > 0 {
+		result.AlternativePaths = g.alternativePaths(source, target, result.Degrees, opts)
+	}
+	return result, nil
+}
+
+// bidirectionalSearch expands whole layers from whichever side has the smaller
+// frontier until the two searches meet. It returns the distances and number of
+// shortest paths from each side along with the meeting nodes, all of which lie
+// on a shortest path and are at the same distance from source
+func (g *Graph) bidirectionalSearch(source, target int) (map[int]int, map[int]int64, map[int]int, map[int]int64, []int) {
+	distFrom := map[int]int{source: 0}
+	countFrom := map[int]int64{source: 1}
+	distTo := map[int]int{target: 0}
+	countTo := map[int]int64{target: 1}
+	frontierFrom := []int{source}
+	frontierTo := []int{target}
+
+	for len(frontierFrom) > 0 && len(frontierTo) > 0 {
+		forwards := len(frontierFrom) <= len(frontierTo)
+		frontier, dist, count, otherDist := frontierFrom, distFrom, countFrom, distTo
+		if !forwards {
+			frontier, dist, count, otherDist = frontierTo, distTo, countTo, distFrom
+		}
+
+		next := []int{}
+		meeting := []int{}
+		for _, u := range frontier {
+			for _, v := range g.adj[u] {
+				if d, seen := dist[v]; seen {
+					if d == dist[u]+1 {
+						count[v] = saturatingAdd(count[v], count[u])
+					}
+					continue
+				}
+				dist[v] = dist[u] + 1
+				count[v] = count[u]
+				next = append(next, v)
+				if _, met := otherDist[v]; met {
+					meeting = append(meeting, v)
+				}
+			}
+		}
+
+		if forwards {
+			frontierFrom = next
+		} else {
+			frontierTo = next
+		}
+		if len(meeting) > 0 {
+			return distFrom, countFrom, distTo, countTo, meeting
+		}
+	}
+	return distFrom, countFrom, distTo, countTo, nil
+}
+
+// enumerateHalfPaths walks back from v to the start of a search (distance 0)
+// along nodes one step closer each time, calling fn with each path found
+// starting at v. It stops once fn returns false
+func (g *Graph) enumerateHalfPaths(v int, dist map[int]int, fn func([]int) bool) {
+	path := []int{v}
+	var walk func(u int) bool
+	walk = func(u int) bool {
+		if dist[u] == 0 {
+			return fn(path)
+		}
+		for _, w := range g.adj[u] {
+			if d, seen := dist[w]; seen && d == dist[u]-1 {
+				path = append(path, w)
+				keepGoing := walk(w)
+				path = path[:len(path)-1]
+				if !keepGoing {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	walk(v)
+}
+
+// alternativePaths enumerates simple paths between source and target that
+// are longer than the shortest path but within opts.MaxExtraHops of it.
+// Shorter paths are found first as each length is searched in turn
+func (g *Graph) alternativePaths(source, target, shortest int, opts PathOptions) [][]string {
+	distToTarget := g.bfs(target)
+	paths := [][]string{}
+	onPath := make([]bool, len(g.nodes))
+	path := []int{source}
+	onPath[source] = true
+
+	var walk func(u, length int) bool
+	walk = func(u, length int) bool {
+		if u == target {
+			if len(path)-1 == length {
+				steamIDs := make([]string, len(path))
+				for i, v := range path {
+					steamIDs[i] = g.nodes[v].SteamID
+				}
+				paths = append(paths, steamIDs)
+			}
+			return len(paths) < opts.MaxAlternativePaths
+		}
+		for _, v := range g.adj[u] {
+			if onPath[v] || distToTarget[v] < 0 || len(path)+distToTarget[v] > length {
+				continue
+			}
+			onPath[v] = true
+			path = append(path, v)
+			keepGoing := walk(v, length)
+			path = path[:len(path)-1]
+			onPath[v] = false
+			if !keepGoing {
+				return false
+			}
+		}
+		return true
+	}
+
+	for length := shortest + 1; length <= shortest+opts.MaxExtraHops; length++ {
+		if !walk(source, length) {
+			break
+		}
+	}
+	return paths
+}
+
+// bfs returns the distance of every node from source, -1 if unreachable
+func (g *Graph) bfs(source int) []int {
+	dist := make([]int, len(g.nodes))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[source] = 0
+	queue := []int{source}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range g.adj[u] {
+			if dist[v] == -1 {
+				dist[v] = dist[u] + 1
+				queue = append(queue, v)
+			}
+		}
+	}
+	return dist
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGridGraph returns a width x height grid where node "x,y" is
+// connected to its horizontal and vertical neighbours
+func newGridGraph(width, height int) *Graph {
+	g := New()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g.AddNode(Node{SteamID: fmt.Sprintf("%d,%d", x, y)})
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g.AddEdge(fmt.Sprintf("%d,%d", x, y), fmt.Sprintf("%d,%d", x+1, y))
+			g.AddEdge(fmt.Sprintf("%d,%d", x, y), fmt.Sprintf("%d,%d", x, y+1))
+		}
+	}
+	return g
+}
+
+func TestShortestPathsBetweenFriendsOfFriends(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	result, err := g.ShortestPaths("carol", "dave", DefaultPathOptions)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Degrees)
+	assert.Equal(t, int64(2), result.ShortestPathCount)
+	assert.Equal(t, [][]string{
+		{"carol", "root", "alice", "dave"},
+		{"carol", "root", "bob", "dave"},
+	}, result.ShortestPaths)
+	assert.Equal(t, [][]string{
+		{"carol", "root", "alice", "bob", "dave"},
+		{"carol", "root", "bob", "alice", "dave"},
+	}, result.AlternativePaths)
+}
+
+func TestShortestPathsBetweenDirectFriends(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	result, err := g.ShortestPaths("root", "alice", PathOptions{MaxShortestPaths: 5})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Degrees)
+	assert.Equal(t, int64(1), result.ShortestPathCount)
+	assert.Equal(t, [][]string{{"root", "alice"}}, result.ShortestPaths)
+	assert.Empty(t, result.AlternativePaths)
+}
+
+func TestShortestPathsCountsEveryPathButReturnsAtMostTheLimit(t *testing.T) {
+	g := newGridGraph(5, 5)
+
+	result, err := g.ShortestPaths("0,0", "4,4", PathOptions{MaxShortestPaths: 3})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 8, result.Degrees)
+	// 8 choose 4 monotonic paths across the grid
+	assert.Equal(t, int64(70), result.ShortestPathCount)
+	assert.Len(t, result.ShortestPaths, 3)
+	for _, path := range result.ShortestPaths {
+		assert.Len(t, path, 9)
+		assert.Equal(t, "0,0", path[0])
+		assert.Equal(t, "4,4", path[8])
+		for i := 1; i < len(path); i++ {
+			assert.True(t, g.HasEdge(path[i-1], path[i]))
+		}
+	}
+}
+
+func TestShortestPathsAlternativesAreBounded(t *testing.T) {
+	g := newGridGraph(4, 4)
+
+	result, err := g.ShortestPaths("0,0", "3,3", PathOptions{MaxShortestPaths: 1, MaxAlternativePaths: 4, MaxExtraHops: 2})
+
+	assert.Nil(t, err)
+	assert.Len(t, result.AlternativePaths, 4)
+	for _, path := range result.AlternativePaths {
+		// paths in a grid have the same parity so are 2 hops longer
+		assert.Len(t, path, 9)
+	}
+}
+
+func TestShortestPathsToSelf(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	result, err := g.ShortestPaths("root", "root", DefaultPathOptions)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.Degrees)
+	assert.Equal(t, [][]string{{"root"}}, result.ShortestPaths)
+}
+
+func TestShortestPathsReturnsErrNoPathForDisconnectedUsers(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+	g.AddNode(Node{SteamID: "erin"})
+
+	_, err := g.ShortestPaths("root", "erin", DefaultPathOptions)
+
+	assert.Equal(t, ErrNoPath, err)
+}
+
+func TestShortestPathsReturnsAnErrorForUnknownUsers(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	_, err := g.ShortestPaths("root", "erin", DefaultPathOptions)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,270 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// PruneStrategy decides which users are kept when a
+// graph is reduced to fit a node budget
+type PruneStrategy string
+
+const (
+	// PruneKCore keeps the users in the most tightly connected
+	// part of the graph, those with the highest core number
+	PruneKCore PruneStrategy = "kcore"
+	// PruneTopDegree keeps the users with the most friends
+	PruneTopDegree PruneStrategy = "topdegree"
+	// PruneRandomWalk keeps users in the order a random walk from
+	// the crawl target first visits them
+	PruneRandomWalk PruneStrategy = "randomwalk"
+	// PrunePathToRoot keeps the users closest to the crawl target
+	PrunePathToRoot PruneStrategy = "pathtoroot"
+)
+
+// PruneOptions configures Prune
+type PruneOptions struct {
+	Strategy PruneStrategy
+	// MaxNodes is the most users the pruned graph may contain
+	MaxNodes int
+	// Seed and RestartProbability are only used by PruneRandomWalk,
+	// the walk jumps back to the crawl target with RestartProbability
+	// at each step
+	Seed               int64
+	RestartProbability float64
+}
+
+// DefaultPruneOptions keeps few enough users for the graph view to stay responsive
+var DefaultPruneOptions = PruneOptions{
+	Strategy:           PruneKCore,
+	MaxNodes:           1000,
+	Seed:               1,
+	RestartProbability: 0.15,
+}
+
+// PruneReport describes what was removed from a graph by Prune
+type PruneReport struct {
+	Strategy  PruneStrategy `json:"strategy"`
+	MaxNodes  int           `json:"maxnodes"`
+	KeptNodes int           `json:"keptnodes"`
+	// DroppedNodes are the steamIDs of removed users in node order
+	DroppedNodes []string `json:"droppednodes"`
+	DroppedEdges int      `json:"droppededges"`
+}
+
+// Prune reduces the graph to at most opts.MaxNodes users. Users are ranked by
+// the strategy and added best first along with the users linking them to the
+// crawl target, so every kept user can still be reached from it. Users not
+// connected to the crawl target are always dropped. If the graph has no root
+// the first user is treated as the crawl target
+func (g *Graph) Prune(opts PruneOptions) (*Graph, PruneReport, error) {
+	if opts.MaxNodes < 1 {
+		return nil, PruneReport{}, errors.New("max nodes must be at least 1")
+	}
+	if len(g.nodes) == 0 {
+		return New(), PruneReport{Strategy: opts.Strategy, MaxNodes: opts.MaxNodes, DroppedNodes: []string{}}, nil
+	}
+	root, exists := g.index[g.root]
+	if !exists {
+		root = 0
+	}
+
+	var ranking []int
+	switch opts.Strategy {
+	case PruneKCore:
+		core := g.coreNumbers()
+		ranking = g.rankBy(func(a, b int) bool {
+			if core[a] != core[b] {
+				return core[a] > core[b]
+			}
+			return len(g.adj[a]) > len(g.adj[b])
+		})
+	case PruneTopDegree:
+		ranking = g.rankBy(func(a, b int) bool {
+			return len(g.adj[a]) > len(g.adj[b])
+		})
+	case PruneRandomWalk:
+		ranking = g.randomWalk(root, opts)
+	case PrunePathToRoot:
+		dist := g.bfs(root)
+		ranking = g.rankBy(func(a, b int) bool {
+			return dist[a] < dist[b]
+		})
+	default:
+		return nil, PruneReport{}, fmt.Errorf("unknown prune strategy: %q", opts.Strategy)
+	}
+
+	kept := g.keepConnected(root, ranking, opts.MaxNodes)
+	pruned := g.Subgraph(func(node Node) bool {
+		return kept[g.index[node.SteamID]]
+	})
+	report := PruneReport{
+		Strategy:     opts.Strategy,
+		MaxNodes:     opts.MaxNodes,
+		KeptNodes:    pruned.NodeCount(),
+		DroppedNodes: []string{},
+		DroppedEdges: g.edges - pruned.EdgeCount(),
+	}
+	for i, node := range g.nodes {
+		if !kept[i] {
+			report.DroppedNodes = append(report.DroppedNodes, node.SteamID)
+		}
+	}
+	return pruned, report, nil
+}
+
+// PruneUsersGraphData prunes a crawl, see Prune. Dropped users are removed
+// from FriendDetails, FriendIDs are left as they are in the same way
+// friends that were never crawled are
+func PruneUsersGraphData(data common.UsersGraphData, opts PruneOptions) (common.UsersGraphData, PruneReport, error) {
+	pruned, report, err := FromUsersGraphData(data).Prune(opts)
+	if err != nil {
+		return common.UsersGraphData{}, PruneReport{}, err
+	}
+
+	prunedData := data
+	prunedData.FriendDetails = make([]common.UsersGraphInformation, 0, pruned.NodeCount())
+	for _, user := range data.FriendDetails {
+		if pruned.HasNode(user.User.AccDetails.SteamID) {
+			prunedData.FriendDetails = append(prunedData.FriendDetails, user)
+		}
+	}
+	return prunedData, report, nil
+}
+
+// rankBy returns every node index sorted by less, ties
+// are broken by node order
+func (g *Graph) rankBy(less func(a, b int) bool) []int {
+	ranking := make([]int, len(g.nodes))
+	for i := range ranking {
+		ranking[i] = i
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return less(ranking[i], ranking[j])
+	})
+	return ranking
+}
+
+// keepConnected adds nodes in ranked order along with a shortest path from
+// each to a node already kept, skipping any that would exceed the budget
+func (g *Graph) keepConnected(root int, ranking []int, maxNodes int) []bool {
+	dist := g.bfs(root)
+	kept := make([]bool, len(g.nodes))
+	kept[root] = true
+	count := 1
+	for _, v := range ranking {
+		if count == maxNodes {
+			break
+		}
+		if kept[v] || dist[v] < 0 {
+			continue
+		}
+
+		// Step towards the root, preferring friends already kept
+		path := []int{}
+		for u := v; !kept[u]; {
+			path = append(path, u)
+			next := -1
+			for _, w := range g.adj[u] {
+				if dist[w] == dist[u]-1 {
+					if next == -1 {
+						next = w
+					}
+					if kept[w] {
+						next = w
+						break
+					}
+				}
+			}
+			u = next
+		}
+		if count+len(path) > maxNodes {
+			continue
+		}
+		for _, u := range path {
+			kept[u] = true
+		}
+		count += len(path)
+	}
+	return kept
+}
+
+// coreNumbers returns the core number of each node, the largest k for which
+// it is in a subgraph where every node has at least k friends. This is the
+// linear time peeling algorithm of Batagelj and Zaversnik
+func (g *Graph) coreNumbers() []int {
+	n := len(g.nodes)
+	degree := make([]int, n)
+	maxDegree := 0
+	for i, neighbours := range g.adj {
+		degree[i] = len(neighbours)
+		if degree[i] > maxDegree {
+			maxDegree = degree[i]
+		}
+	}
+
+	// Nodes sorted by degree, with bin[d] the first position of degree d
+	bin := make([]int, maxDegree+1)
+	for _, d := range degree {
+		bin[d]++
+	}
+	start := 0
+	for d, size := range bin {
+		bin[d] = start
+		start += size
+	}
+	order := make([]int, n)
+	position := make([]int, n)
+	for i, d := range degree {
+		position[i] = bin[d]
+		order[position[i]] = i
+		bin[d]++
+	}
+	for d := maxDegree; d > 0; d-- {
+		bin[d] = bin[d-1]
+	}
+	bin[0] = 0
+
+	for _, v := range order {
+		for _, u := range g.adj[v] {
+			if degree[u] > degree[v] {
+				// Swap u with the first node of its degree then shrink that bin
+				first := order[bin[degree[u]]]
+				if first != u {
+					order[position[u]], order[bin[degree[u]]] = first, u
+					position[first], position[u] = position[u], bin[degree[u]]
+				}
+				bin[degree[u]]++
+				degree[u]--
+			}
+		}
+	}
+	return degree
+}
+
+// randomWalk returns nodes in the order a random walk with restarts from
+// root first visits them, stopping once enough nodes have been seen or
+// after a bounded number of steps
+func (g *Graph) randomWalk(root int, opts PruneOptions) []int {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	visited := make([]bool, len(g.nodes))
+	visited[root] = true
+	ranking := []int{root}
+	current := root
+	for step := 0; step < 100*opts.MaxNodes && len(ranking) < opts.MaxNodes; step++ {
+		if len(g.adj[current]) == 0 || rng.Float64() < opts.RestartProbability {
+			current = root
+			continue
+		}
+		current = g.adj[current][rng.Intn(len(g.adj[current]))]
+		if !visited[current] {
+			visited[current] = true
+			ranking = append(ranking, current)
+		}
+	}
+	return ranking
+}
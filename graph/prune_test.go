@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneKeepsEveryUserConnectedToTheRoot(t *testing.T) {
+	g := newSyntheticGraph(500, 2, 1)
+	g.AddNode(Node{SteamID: "loner"})
+	g.SetRoot("250")
+
+	for _, strategy := range []PruneStrategy{PruneKCore, PruneTopDegree, PruneRandomWalk, PrunePathToRoot} {
+		opts := DefaultPruneOptions
+		opts.Strategy = strategy
+		opts.MaxNodes = 50
+
+		pruned, report, err := g.Prune(opts)
+
+		assert.Nil(t, err, strategy)
+		assert.LessOrEqual(t, pruned.NodeCount(), 50, strategy)
+		assert.Equal(t, pruned.NodeCount(), report.KeptNodes, strategy)
+		assert.Equal(t, g.NodeCount(), report.KeptNodes+len(report.DroppedNodes), strategy)
+		assert.Equal(t, g.EdgeCount(), pruned.EdgeCount()+report.DroppedEdges, strategy)
+		assert.Contains(t, report.DroppedNodes, "loner", strategy)
+
+		root, exists := pruned.Root()
+		assert.True(t, exists, strategy)
+		assert.Equal(t, "250", root.SteamID, strategy)
+		for _, dist := range pruned.bfs(pruned.index["250"]) {
+			assert.GreaterOrEqual(t, dist, 0, strategy)
+		}
+	}
+}
+
+func TestPruneKCoreKeepsDenseGroup(t *testing.T) {
+	g := newCliquesGraph(1, 5)
+	g.AddNode(Node{SteamID: "chain0"})
+	g.AddEdge("chain0", "0-0")
+	for i := 1; i < 5; i++ {
+		g.AddNode(Node{SteamID: fmt.Sprintf("chain%d", i)})
+		g.AddEdge(fmt.Sprintf("chain%d", i-1), fmt.Sprintf("chain%d", i))
+	}
+	g.SetRoot("chain0")
+
+	pruned, report, err := g.Prune(PruneOptions{Strategy: PruneKCore, MaxNodes: 6})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"0-0", "0-1", "0-2", "0-3", "0-4", "chain0"}, sortedIDs(pruned))
+	assert.Equal(t, []string{"chain1", "chain2", "chain3", "chain4"}, report.DroppedNodes)
+	assert.Equal(t, 4, report.DroppedEdges)
+}
+
+func TestPruneTopDegreeAddsPathToRoot(t *testing.T) {
+	g := newStarGraph(5)
+	g.SetRoot("leaf0")
+
+	pruned, report, err := g.Prune(PruneOptions{Strategy: PruneTopDegree, MaxNodes: 3})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"centre", "leaf0", "leaf1"}, sortedIDs(pruned))
+	assert.Equal(t, []string{"leaf2", "leaf3", "leaf4"}, report.DroppedNodes)
+}
+
+func TestPrunePathToRootKeepsLowestLevels(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	pruned, report, err := g.Prune(PruneOptions{Strategy: PrunePathToRoot, MaxNodes: 4})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol", "root"}, sortedIDs(pruned))
+	assert.Equal(t, []string{"dave"}, report.DroppedNodes)
+	assert.Equal(t, 2, report.DroppedEdges)
+}
+
+func TestPruneRandomWalkIsDeterministicForASeed(t *testing.T) {
+	g := newSyntheticGraph(500, 2, 1)
+	g.SetRoot("0")
+	opts := DefaultPruneOptions
+	opts.Strategy = PruneRandomWalk
+	opts.MaxNodes = 40
+
+	first, _, err := g.Prune(opts)
+	assert.Nil(t, err)
+	second, _, err := g.Prune(opts)
+	assert.Nil(t, err)
+
+	assert.Equal(t, first.SteamIDs(), second.SteamIDs())
+}
+
+func TestPruneRejectsInvalidOptions(t *testing.T) {
+	g := FromUsersGraphData(newTestUsersGraphData())
+
+	_, _, err := g.Prune(PruneOptions{Strategy: "unknown", MaxNodes: 10})
+	assert.Error(t, err)
+	_, _, err = g.Prune(PruneOptions{Strategy: PruneKCore})
+	assert.Error(t, err)
+}
+
+func TestPruneUsersGraphData(t *testing.T) {
+	data := newTestUsersGraphData()
+
+	pruned, report, err := PruneUsersGraphData(data, PruneOptions{Strategy: PrunePathToRoot, MaxNodes: 4})
+
+	assert.Nil(t, err)
+	assert.Equal(t, data.UserDetails, pruned.UserDetails)
+	assert.Len(t, pruned.FriendDetails, 3)
+	for _, user := range pruned.FriendDetails {
+		assert.NotEqual(t, "dave", user.User.AccDetails.SteamID)
+	}
+	assert.Equal(t, []string{"dave"}, report.DroppedNodes)
+}
+
+func sortedIDs(g *Graph) []string {
+	ids := g.SteamIDs()
+	sort.Strings(ids)
+	return ids
+}
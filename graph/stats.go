@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"sort"
+	"time"
+)
+
+// LevelStats counts the users and friendships found at a crawl level. An
+// edge belongs to the deeper level of the two users it connects
+type LevelStats struct {
+	Level int `json:"level"`
+	Nodes int `json:"nodes"`
+	Edges int `json:"edges"`
+}
+
+// HistogramBucket counts how many values fell in [Min, Max]
+type HistogramBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// NetworkStats summarises a friend graph for the stats panel of a crawl
+type NetworkStats struct {
+	Nodes  int          `json:"nodes"`
+	Edges  int          `json:"edges"`
+	Levels []LevelStats `json:"levels"`
+	// Density is the fraction of all possible friendships that exist
+	Density float64 `json:"density"`
+	// ClusteringCoefficient is the average fraction of each user's
+	// friends that are also friends with each other
+	ClusteringCoefficient float64 `json:"clusteringcoefficient"`
+	// DiameterEstimate is a lower bound on the longest shortest path
+	// between two users, found by repeated breadth first searches
+	DiameterEstimate   int               `json:"diameterestimate"`
+	DegreeDistribution []HistogramBucket `json:"degreedistribution"`
+	// CountryDistribution counts users by Loccountrycode, users
+	// who have not set a country are counted under ""
+	CountryDistribution map[string]int `json:"countrydistribution"`
+	// AccountAgeDistribution counts users by whole years since their
+	// account was created, users with no Timecreated are skipped
+	AccountAgeDistribution []HistogramBucket `json:"accountagedistribution"`
+}
+
+// Stats summarises the graph, account ages are measured from now
+func (g *Graph) Stats(now time.Time) NetworkStats {
+	stats := NetworkStats{
+		Nodes:                  len(g.nodes),
+		Edges:                  g.edges,
+		Levels:                 g.levelStats(),
+		ClusteringCoefficient:  g.clusteringCoefficient(),
+		DiameterEstimate:       g.diameterEstimate(),
+		DegreeDistribution:     g.degreeDistribution(),
+		CountryDistribution:    make(map[string]int),
+		AccountAgeDistribution: []HistogramBucket{},
+	}
+	if n := len(g.nodes); n > 1 {
+		stats.Density = 2 * float64(g.edges) / float64(n*(n-1))
+	}
+
+	ages := make(map[int]int)
+	for _, node := range g.nodes {
+		stats.CountryDistribution[node.User.AccDetails.Loccountrycode]++
+		if created := node.User.AccDetails.Timecreated; created > 0 {
+			age := int(now.Sub(time.Unix(int64(created), 0)).Hours() / (24 * 365.25))
+			if age < 0 {
+				age = 0
+			}
+			ages[age]++
+		}
+	}
+	for age, count := range ages {
+		stats.AccountAgeDistribution = append(stats.AccountAgeDistribution, HistogramBucket{Min: age, Max: age, Count: count})
+	}
+	sort.Slice(stats.AccountAgeDistribution, func(i, j int) bool {
+		return stats.AccountAgeDistribution[i].Min < stats.AccountAgeDistribution[j].Min
+	})
+	return stats
+}
+
+func (g *Graph) levelStats() []LevelStats {
+	byLevel := make(map[int]*LevelStats)
+	levelOf := func(level int) *LevelStats {
+		if _, exists := byLevel[level]; !exists {
+			byLevel[level] = &LevelStats{Level: level}
+		}
+		return byLevel[level]
+	}
+	for i, node := range g.nodes {
+		levelOf(node.Level).Nodes++
+		for _, j := range g.adj[i] {
+			if i < j {
+				level := node.Level
+				if g.nodes[j].Level > level {
+					level = g.nodes[j].Level
+				}
+				levelOf(level).Edges++
+			}
+		}
+	}
+
+	levels := make([]LevelStats, 0, len(byLevel))
+	for _, level := range byLevel {
+		levels = append(levels, *level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Level < levels[j].Level
+	})
+	return levels
+}
+
+// clusteringCoefficient averages the local clustering coefficient of every
+// user, users with fewer than two friends count as 0
+func (g *Graph) clusteringCoefficient() float64 {
+	if len(g.nodes) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, neighbours := range g.adj {
+		if len(neighbours) < 2 {
+			continue
+		}
+		links := 0
+		for a := 0; a < len(neighbours); a++ {
+			for b := a + 1; b < len(neighbours); b++ {
+				if g.hasEdgeByIndex(neighbours[a], neighbours[b]) {
+					links++
+				}
+			}
+		}
+		total += 2 * float64(links) / float64(len(neighbours)*(len(neighbours)-1))
+	}
+	return total / float64(len(g.nodes))
+}
+
+// diameterEstimate runs a double sweep from the root (or the first node)
+// of every connected component: a search to find the furthest node and
+// then a search from there, which is exact for trees
+func (g *Graph) diameterEstimate() int {
+	diameter := 0
+	visited := make([]bool, len(g.nodes))
+	starts := []int{}
+	if root, exists := g.index[g.root]; exists {
+		starts = append(starts, root)
+	}
+	for i := range g.nodes {
+		starts = append(starts, i)
+	}
+
+	for _, start := range starts {
+		if visited[start] {
+			continue
+		}
+		dist := g.bfs(start)
+		furthest := start
+		for i, d := range dist {
+			if d >= 0 {
+				visited[i] = true
+				if d > dist[furthest] {
+					furthest = i
+				}
+			}
+		}
+		for _, d := range g.bfs(furthest) {
+			if d > diameter {
+				diameter = d
+			}
+		}
+	}
+	return diameter
+}
+
+// degreeDistribution buckets users by their number of friends in powers of
+// two (0, 1, 2-3, 4-7, ...) as friend counts tend to have a long tail
+func (g *Graph) degreeDistribution() []HistogramBucket {
+	buckets := []HistogramBucket{}
+	for _, neighbours := range g.adj {
+		degree := len(neighbours)
+		bucket := 0
+		for upper := 0; degree > upper; upper = upper*2 + 1 {
+			bucket++
+		}
+		for len(buckets) <= bucket {
+			lower := 0
+			if b := len(buckets); b > 0 {
+				lower = 1 << (b - 1)
+			}
+			buckets = append(buckets, HistogramBucket{Min: lower, Max: 2*lower - 1})
+		}
+		buckets[bucket].Count++
+	}
+	if len(buckets) > 0 {
+		buckets[0].Max = 0
+	}
+	return buckets
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsOfCrawl(t *testing.T) {
+	data := newTestUsersGraphData()
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	data.UserDetails.User.AccDetails.Loccountrycode = "IE"
+	data.UserDetails.User.AccDetails.Timecreated = int(now.AddDate(-10, 0, -1).Unix())
+	data.FriendDetails[0].User.AccDetails.Loccountrycode = "IE"
+	data.FriendDetails[0].User.AccDetails.Timecreated = int(now.AddDate(-2, -6, 0).Unix())
+	data.FriendDetails[1].User.AccDetails.Loccountrycode = "DE"
+	data.FriendDetails[1].User.AccDetails.Timecreated = int(now.AddDate(-2, 0, -1).Unix())
+
+	stats := FromUsersGraphData(data).Stats(now)
+
+	assert.Equal(t, 5, stats.Nodes)
+	assert.Equal(t, 6, stats.Edges)
+	assert.Equal(t, []LevelStats{
+		{Level: 0, Nodes: 1, Edges: 0},
+		{Level: 1, Nodes: 3, Edges: 4},
+		{Level: 2, Nodes: 1, Edges: 2},
+	}, stats.Levels)
+	assert.InDelta(t, 0.6, stats.Density, 1e-9)
+	// root 1/3, alice 2/3, bob 2/3, dave 1
+	assert.InDelta(t, (1.0/3+2.0/3+2.0/3+1)/5, stats.ClusteringCoefficient, 1e-9)
+	assert.Equal(t, 3, stats.DiameterEstimate)
+	assert.Equal(t, []HistogramBucket{
+		{Min: 0, Max: 0, Count: 0},
+		{Min: 1, Max: 1, Count: 1},
+		{Min: 2, Max: 3, Count: 4},
+	}, stats.DegreeDistribution)
+	assert.Equal(t, map[string]int{"IE": 2, "DE": 1, "": 2}, stats.CountryDistribution)
+	assert.Equal(t, []HistogramBucket{
+		{Min: 2, Max: 2, Count: 2},
+		{Min: 10, Max: 10, Count: 1},
+	}, stats.AccountAgeDistribution)
+}
+
+func TestStatsDiameterAcrossComponents(t *testing.T) {
+	g := newGridGraph(3, 3)
+	g.AddNode(Node{SteamID: "loner"})
+
+	stats := g.Stats(time.Now())
+
+	assert.Equal(t, 4, stats.DiameterEstimate)
+	assert.Equal(t, HistogramBucket{Min: 0, Max: 0, Count: 1}, stats.DegreeDistribution[0])
+}
+
+func TestStatsOfEmptyGraph(t *testing.T) {
+	stats := New().Stats(time.Now())
+
+	assert.Equal(t, 0, stats.Nodes)
+	assert.Equal(t, 0.0, stats.Density)
+	assert.Empty(t, stats.Levels)
+	assert.Empty(t, stats.DegreeDistribution)
+}
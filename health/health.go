@@ -0,0 +1,192 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/util"
+)
+
+const (
+	// StatusOK means every check passed
+	StatusOK = "ok"
+	// StatusDegraded means only non-critical checks failed
+	StatusDegraded = "degraded"
+	// StatusUnavailable means at least one critical check failed
+	StatusUnavailable = "unavailable"
+	// StatusFailing is the status of an individual check that failed
+	StatusFailing = "failing"
+
+	// DefaultTimeout is used for checks registered without a timeout
+	DefaultTimeout = 5 * time.Second
+)
+
+// CheckFunc checks a single dependency, returning an error if
+// it is unhealthy. It should return when ctx is done
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named health check. Critical checks that fail mark the service
+// as unavailable whereas non-critical checks only mark it as degraded
+type Check struct {
+	Name     string
+	Check    CheckFunc
+	Timeout  time.Duration
+	Critical bool
+}
+
+// registeredCheck holds a check along with the last error it returned
+type registeredCheck struct {
+	Check
+	lastError     string
+	lastErrorTime int64
+}
+
+// Health holds the registered checks for a service and serves its
+// liveness and readiness endpoints
+type Health struct {
+	mu        sync.Mutex
+	startTime time.Time
+	version   string
+	checks    []*registeredCheck
+}
+
+// New creates a Health for a service with the given build version,
+// uptime is measured from when this is called
+func New(version string) *Health {
+	return &Health{
+		startTime: time.Now(),
+		version:   version,
+	}
+}
+
+// Register adds a check that is run on every readiness request
+func (h *Health) Register(check Check) error {
+	if check.Name == "" || check.Check == nil {
+		return errors.New("health checks must have a name and check function")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, existing := range h.checks {
+		if existing.Name == check.Name {
+			return fmt.Errorf("health check %q is already registered", check.Name)
+		}
+	}
+	h.checks = append(h.checks, &registeredCheck{Check: check})
+	return nil
+}
+
+// Live returns the liveness of the service, which only
+// reports that the process is up and able to respond
+func (h *Health) Live() common.HealthResponse {
+	return common.HealthResponse{
+		Status:  StatusOK,
+		Version: h.version,
+		Uptime:  time.Since(h.startTime),
+	}
+}
+
+// Ready runs every registered check concurrently and returns
+// the status of each along with the overall status
+func (h *Health) Ready(ctx context.Context) common.HealthResponse {
+	h.mu.Lock()
+	checks := make([]*registeredCheck, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.Unlock()
+
+	results := make([]common.CheckStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *registeredCheck) {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := h.Live()
+	response.Checks = results
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			response.Status = StatusUnavailable
+			break
+		}
+		response.Status = StatusDegraded
+	}
+	return response
+}
+
+// run executes a single check within its timeout
+func (h *Health) run(ctx context.Context, check *registeredCheck) common.CheckStatus {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- check.Check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errChan:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", check.Timeout)
+	}
+	latency := time.Since(start)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := StatusOK
+	if err != nil {
+		status = StatusFailing
+		check.lastError = err.Error()
+		check.lastErrorTime = util.GetCurrentTimeInMs()
+	}
+	return common.CheckStatus{
+		Name:          check.Name,
+		Status:        status,
+		Critical:      check.Critical,
+		Latency:       latency,
+		LastError:     check.lastError,
+		LastErrorTime: check.lastErrorTime,
+	}
+}
+
+// LivenessHandler serves Live, it always responds with 200
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealthResponse(w, h.Live())
+	})
+}
+
+// ReadinessHandler serves Ready, responding with 503 when a
+// critical check fails and 200 otherwise
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeHealthResponse(w, h.Ready(req.Context()))
+	})
+}
+
+func writeHealthResponse(w http.ResponseWriter, response common.HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if response.Status == StatusUnavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func passingCheck(ctx context.Context) error {
+	return nil
+}
+
+func failingCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRegisterRejectsDuplicateNames(t *testing.T) {
+	h := New("v1.0.0")
+
+	assert.Nil(t, h.Register(Check{Name: "database", Check: passingCheck}))
+	assert.Error(t, h.Register(Check{Name: "database", Check: passingCheck}))
+	assert.Error(t, h.Register(Check{Name: "", Check: passingCheck}))
+}
+
+func TestReadyWithAllChecksPassing(t *testing.T) {
+	h := New("v1.0.0")
+	h.Register(Check{Name: "database", Check: passingCheck, Critical: true})
+	h.Register(Check{Name: "steamapi", Check: passingCheck})
+
+	response := h.Ready(context.Background())
+
+	assert.Equal(t, StatusOK, response.Status)
+	assert.Equal(t, "v1.0.0", response.Version)
+	assert.Len(t, response.Checks, 2)
+	assert.Equal(t, "database", response.Checks[0].Name)
+	assert.Equal(t, StatusOK, response.Checks[1].Status)
+}
+
+func TestReadyIsDegradedWhenANonCriticalCheckFails(t *testing.T) {
+	h := New("v1.0.0")
+	h.Register(Check{Name: "database", Check: passingCheck, Critical: true})
+	h.Register(Check{Name: "steamapi", Check: failingCheck})
+
+	response := h.Ready(context.Background())
+
+	assert.Equal(t, StatusDegraded, response.Status)
+	assert.Equal(t, StatusFailing, response.Checks[1].Status)
+	assert.Equal(t, "connection refused", response.Checks[1].LastError)
+}
+
+func TestReadyIsUnavailableWhenACriticalCheckTimesOut(t *testing.T) {
+	h := New("v1.0.0")
+	h.Register(Check{
+		Name: "database",
+		Check: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+		Timeout:  10 * time.Millisecond,
+		Critical: true,
+	})
+
+	response := h.Ready(context.Background())
+
+	assert.Equal(t, StatusUnavailable, response.Status)
+	assert.Contains(t, response.Checks[0].LastError, "timed out")
+}
+
+func TestReadyKeepsLastErrorAfterRecovering(t *testing.T) {
+	shouldFail := true
+	h := New("v1.0.0")
+	h.Register(Check{Name: "database", Check: func(ctx context.Context) error {
+		if shouldFail {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+
+	h.Ready(context.Background())
+	shouldFail = false
+	response := h.Ready(context.Background())
+
+	assert.Equal(t, StatusOK, response.Checks[0].Status)
+	assert.Equal(t, "connection refused", response.Checks[0].LastError)
+	assert.Greater(t, response.Checks[0].LastErrorTime, int64(0))
+}
+
+func TestReadinessHandlerRespondsWithServiceUnavailable(t *testing.T) {
+	h := New("v1.0.0")
+	h.Register(Check{Name: "database", Check: failingCheck, Critical: true})
+
+	res := httptest.NewRecorder()
+	h.ReadinessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	response := common.HealthResponse{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&response))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, StatusUnavailable, response.Status)
+}
+
+func TestLivenessHandlerDoesNotRunChecks(t *testing.T) {
+	h := New("v1.0.0")
+	h.Register(Check{Name: "database", Check: failingCheck, Critical: true})
+
+	res := httptest.NewRecorder()
+	h.LivenessHandler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/live", nil))
+
+	response := common.HealthResponse{}
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&response))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, StatusOK, response.Status)
+	assert.Empty(t, response.Checks)
+}
@@ -0,0 +1,72 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+	"github.com/neosteamfriendgraphing/common/util"
+)
+
+// DefaultInfo describes the services' API
+var DefaultInfo = Info{
+	Title:       "NeoSteamFriendGraphing",
+	Description: "Endpoints of the services that crawl and graph steam friend networks",
+	Version:     "1.0.0",
+}
+
+// steamIDParam and crawlIDParam describe the
+// path parameters shared by several endpoints
+const (
+	steamIDParam = "A user's 64 bit steamID"
+	crawlIDParam = "The ID given to a crawl when it was started"
+)
+
+// Endpoints is every endpoint documented by the dtos package, add an
+// endpoint here when adding its DTOs and then run go generate ./openapi
+var Endpoints = []Endpoint{
+	{Method: http.MethodGet, Path: "/status", Summary: "How long the service has been running", Response: common.UptimeResponse{}},
+	{Method: http.MethodGet, Path: "/version", Summary: "What the service was built from", Response: common.VersionResponse{}},
+	{Method: http.MethodPost, Path: "/saveuser", Summary: "Save a crawled user and their games", Request: dtos.SaveUserDTO{}, Response: common.BasicAPIResponse{}},
+	{Method: http.MethodGet, Path: "/getuser/{steamid}", Summary: "A saved user", PathParams: map[string]string{"steamid": steamIDParam}, Response: dtos.GetUserDTO{}},
+	{Method: http.MethodPost, Path: "/savecrawlingstats", Summary: "Record how far through a crawl is", Request: dtos.SaveCrawlingStatsDTO{}, Response: common.BasicAPIResponse{}},
+	{Method: http.MethodPost, Path: "/crawl", Summary: "Start crawling the friend network of one or two users", Request: dtos.CrawlUsersInputDTO{}, Response: common.BasicAPIResponse{}},
+	{Method: http.MethodGet, Path: "/getcrawlingstatus/{crawlid}", Summary: "How far through a crawl is", PathParams: map[string]string{"crawlid": crawlIDParam}, Response: dtos.GetCrawlingStatusDTO{}},
+	{Method: http.MethodGet, Path: "/getgraphabledata/{steamid}", Summary: "A user and their friends", PathParams: map[string]string{"steamid": steamIDParam}, Response: dtos.GetGraphableDataForUserDTO{}},
+	{Method: http.MethodPost, Path: "/getusernamesfromsteamids", Summary: "Look up the usernames of steamIDs", Request: dtos.GetUsernamesFromSteamIDsInputDTO{}, Response: dtos.GetUsernamesFromSteamIDsDTO{}},
+	{Method: http.MethodPost, Path: "/creategraph", Summary: "Process a finished crawl into graph data", Request: dtos.CreateGraphInputDTO{}, Response: common.BasicAPIResponse{}},
+	{Method: http.MethodPost, Path: "/getdetailsforgames", Summary: "Look up the names of games", Request: dtos.GetDetailsForGamesInputDTO{}, Response: dtos.GetDetailsForGamesDTO{}},
+	{Method: http.MethodPost, Path: "/hasbeencrawledbefore", Summary: "Whether a user has been crawled to a level before", Request: dtos.HasBeenCrawledBeforeInputDTO{}, Response: common.BasicAPIResponse{}},
+	{Method: http.MethodPost, Path: "/doesprocessedgraphdataexist/{crawlid}", Summary: "Whether a crawl has been processed", PathParams: map[string]string{"crawlid": crawlIDParam}, Response: dtos.DoesProcessedGraphDataExistDTO{}},
+	{Method: http.MethodPost, Path: "/getprocessedgraphdata/{crawlid}", Summary: "The processed graph data of a crawl", PathParams: map[string]string{"crawlid": crawlIDParam}, Response: dtos.GetProcessedGraphDataDTO{}},
+	{Method: http.MethodPost, Path: "/getshortestpaths", Summary: "How two users in a crawl are connected", Request: dtos.GetShortestPathsInputDTO{}, Response: dtos.GetShortestPathsDTO{}},
+	{Method: http.MethodPost, Path: "/getsharedgames", Summary: "Games owned by both of two users", Request: dtos.GetSharedGamesInputDTO{}, Response: dtos.GetSharedGamesDTO{}},
+	{Method: http.MethodGet, Path: "/getmostsimilarfriends/{crawlid}/{steamid}", Summary: "A user's friends with the most similar game libraries", PathParams: map[string]string{"crawlid": crawlIDParam, "steamid": steamIDParam}, Response: dtos.GetMostSimilarFriendsDTO{}},
+	{Method: http.MethodGet, Path: "/getrecommendations/{crawlid}/{steamid}", Summary: "Games recommended from a user's friend network", PathParams: map[string]string{"crawlid": crawlIDParam, "steamid": steamIDParam}, Response: dtos.GetRecommendationsDTO{}},
+	{Method: http.MethodGet, Path: "/getnetworkstats/{crawlid}", Summary: "Statistics of a crawl's friend network", PathParams: map[string]string{"crawlid": crawlIDParam}, Response: dtos.GetNetworkStatsDTO{}},
+	{Method: http.MethodPost, Path: "/getcrawldiff", Summary: "How a user's network changed between two crawls", Request: dtos.GetCrawlDiffInputDTO{}, Response: dtos.GetCrawlDiffDTO{}},
+}
+
+// ExtraSchemas are DTOs and documents shared between services
+// that are not the body of any endpoint in Endpoints
+var ExtraSchemas = []interface{}{
+	common.HealthResponse{},
+	common.UsersGraphData{},
+	dtos.Response[dtos.ProcessedGraphDataExistsDTO]{},
+}
+
+// ErrorResponse is the body of invalid and error responses, Fields
+// is only set when util.DecodeAndValidate rejects a request
+type ErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields []util.FieldError `json:"fields,omitempty"`
+}
+
+// Spec generates the OpenAPI document of Endpoints
+func Spec() ([]byte, error) {
+	document, err := Generate(DefaultInfo, Endpoints, ExtraSchemas...)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(document)
+}
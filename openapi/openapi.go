@@ -0,0 +1,371 @@
+package openapi
+
+//go:generate go run ../cmd/openapigen -o openapi.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Version is the version of the OpenAPI specification documents follow
+const Version = "3.0.3"
+
+// Info describes the API a document is for
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Endpoint is a single API endpoint. Request and Response are values of
+// the types sent and returned as JSON, Request is nil when there is no body.
+// Path parameters are written in Path as {name} and described in PathParams
+type Endpoint struct {
+	Method     string
+	Path       string
+	Summary    string
+	PathParams map[string]string
+	Request    interface{}
+	Response   interface{}
+}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Operation is an endpoint within a Document
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path parameter of an Operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body accepted by an Operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response returned by an Operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in a given format
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas of every named type in a Document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema describes a JSON value, named struct types are
+// referenced from Components.Schemas by Ref
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Generate builds an OpenAPI document for the endpoints. Schemas of the
+// extra values are included even if no endpoint uses them
+func Generate(info Info, endpoints []Endpoint, extra ...interface{}) (Document, error) {
+	g := &generator{
+		schemas:    make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+		collected:  make(map[reflect.Type]bool),
+		typesNamed: make(map[string]int),
+	}
+	g.collect(reflect.TypeOf(ErrorResponse{}))
+	for _, endpoint := range endpoints {
+		for _, value := range []interface{}{endpoint.Request, endpoint.Response} {
+			if value != nil {
+				g.collect(reflect.TypeOf(value))
+			}
+		}
+	}
+	for _, value := range extra {
+		g.collect(reflect.TypeOf(value))
+	}
+	document := Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]map[string]Operation),
+		Components: Components{Schemas: g.schemas},
+	}
+	errorSchema := g.schemaOf(reflect.TypeOf(ErrorResponse{}))
+
+	for _, endpoint := range endpoints {
+		method := strings.ToLower(endpoint.Method)
+		if endpoint.Request != nil && (method == "get" || method == "delete") {
+			return Document{}, fmt.Errorf("%s %s cannot have a request body", endpoint.Method, endpoint.Path)
+		}
+		if _, exists := document.Paths[endpoint.Path][method]; exists {
+			return Document{}, fmt.Errorf("%s %s is registered more than once", endpoint.Method, endpoint.Path)
+		}
+
+		names := pathParams(endpoint.Path)
+		if len(names) != len(endpoint.PathParams) {
+			return Document{}, fmt.Errorf("%s %s has path parameters %v but describes %d", endpoint.Method, endpoint.Path, names, len(endpoint.PathParams))
+		}
+		operation := Operation{
+			Summary:     endpoint.Summary,
+			OperationID: strings.SplitN(strings.TrimPrefix(endpoint.Path, "/"), "/", 2)[0],
+			Responses: map[string]Response{
+				"500": jsonResponse("Internal error", errorSchema),
+			},
+		}
+		for _, name := range names {
+			description, exists := endpoint.PathParams[name]
+			if !exists {
+				return Document{}, fmt.Errorf("%s %s does not describe path parameter %s", endpoint.Method, endpoint.Path, name)
+			}
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name:        name,
+				In:          "path",
+				Description: description,
+				Required:    true,
+				Schema:      &Schema{Type: "string"},
+			})
+			operation.Responses["400"] = jsonResponse("Invalid input", errorSchema)
+		}
+		if endpoint.Response != nil {
+			operation.Responses["200"] = jsonResponse("Success", g.schemaOf(reflect.TypeOf(endpoint.Response)))
+		} else {
+			operation.Responses["200"] = Response{Description: "Success"}
+		}
+		if endpoint.Request != nil {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(endpoint.Request))}},
+			}
+			operation.Responses["400"] = jsonResponse("Invalid input", errorSchema)
+		}
+
+		if document.Paths[endpoint.Path] == nil {
+			document.Paths[endpoint.Path] = make(map[string]Operation)
+		}
+		document.Paths[endpoint.Path][method] = operation
+	}
+	for _, value := range extra {
+		g.schemaOf(reflect.TypeOf(value))
+	}
+	return document, nil
+}
+
+// pathParams returns the names of the {name} segments of a path in order
+func pathParams(p string) []string {
+	names := []string{}
+	for _, segment := range strings.Split(p, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// Marshal encodes a document as indented JSON ending with a newline
+func Marshal(document Document) ([]byte, error) {
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+func jsonResponse(description string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// generator builds schemas, keeping every named struct type
+// it sees in schemas so they are only described once
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	// collected are the named struct types found by collect and
+	// typesNamed is how many of them have each name
+	collected  map[reflect.Type]bool
+	typesNamed map[string]int
+}
+
+// collect finds the named struct types reachable from t before any are
+// described, so that types sharing a name are all qualified by their
+// package whatever order they are described in
+func (g *generator) collect(t reflect.Type) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || g.collected[t] {
+		return
+	}
+	if t.Name() != "" {
+		g.collected[t] = true
+		g.typesNamed[typeName(t)]++
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Tag.Get("json") != "-" {
+			g.collect(field.Type)
+		}
+	}
+}
+
+// schemaOf returns the schema of a type following the rules of encoding/json
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.schemaOf(t.Elem())
+		if schema.Ref != "" {
+			// Siblings of $ref are ignored so nullable references are wrapped
+			return &Schema{AllOf: []*Schema{{Ref: schema.Ref}}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.name(t)}
+	}
+	// Interfaces can hold any value
+	return &Schema{}
+}
+
+// name returns the component name of a named struct type, describing it
+// the first time it is seen. Types with the same name in different
+// packages are told apart by their package name
+func (g *generator) name(t reflect.Type) string {
+	if name, exists := g.names[t]; exists {
+		return name
+	}
+	name := typeName(t)
+	if _, taken := g.schemas[name]; taken || g.typesNamed[name] > 1 {
+		name = path.Base(t.PkgPath()) + name
+	}
+	g.names[t] = name
+	// Reserve the name before describing the type in case it refers to itself
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+// typeName returns the name of a type without the brackets and package
+// paths of its type arguments, which are not allowed in component names,
+// so Response[github.com/neosteamfriendgraphing/common/dtos.GetUserDTO]
+// is ResponseGetUserDTO
+func typeName(t reflect.Type) string {
+	name := t.Name()
+	open := strings.Index(name, "[")
+	if open == -1 {
+		return name
+	}
+	typeArgs := strings.Split(strings.TrimSuffix(name[open+1:], "]"), ",")
+	name = name[:open]
+	for _, typeArg := range typeArgs {
+		typeArg = typeArg[strings.LastIndex(typeArg, "/")+1:]
+		typeArg = typeArg[strings.LastIndex(typeArg, ".")+1:]
+		typeArg = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, typeArg)
+		if typeArg != "" {
+			name += strings.ToUpper(typeArg[:1]) + typeArg[1:]
+		}
+	}
+	return name
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the fields of a struct to schema, fields of
+// embedded structs are added as if they were declared in t
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma != -1 {
+			name, options = tag[:comma], tag[comma+1:]
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(schema, fieldType)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.schemaOf(field.Type)
+		for _, option := range strings.Split(options, ",") {
+			if option == "string" {
+				fieldSchema = &Schema{Type: "string"}
+			}
+		}
+		schema.Properties[name] = fieldSchema
+	}
+}
@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommittedSpecIsUpToDate(t *testing.T) {
+	committed, err := os.ReadFile("openapi.json")
+	assert.Nil(t, err)
+
+	spec, err := Spec()
+
+	assert.Nil(t, err)
+	assert.Equal(t, string(committed), string(spec), "openapi.json is out of date, run go generate ./openapi")
+}
+
+type testEmbedded struct {
+	Embedded string `json:"embedded"`
+}
+
+type testRecursive struct {
+	Children []testRecursive `json:"children"`
+}
+
+type testDTO struct {
+	testEmbedded
+	Name       string                   `json:"name"`
+	Count      int64                    `json:"count,omitempty"`
+	Quoted     int                      `json:"quoted,string"`
+	Scores     map[string]float64       `json:"scores"`
+	Optional   *common.CentralityScores `json:"optional,omitempty"`
+	OptionalID *string                  `json:"optionalid"`
+	Raw        []byte                   `json:"raw"`
+	Untagged   bool
+	Skipped    string        `json:"-"`
+	Recursive  testRecursive `json:"recursive"`
+	unexported string
+}
+
+func TestGenerateSchemas(t *testing.T) {
+	document, err := Generate(Info{Title: "test", Version: "1"}, []Endpoint{
+		{Method: "POST", Path: "/test", Request: testDTO{}, Response: common.BasicAPIResponse{}},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"embedded":   {Type: "string"},
+			"name":       {Type: "string"},
+			"count":      {Type: "integer", Format: "int64"},
+			"quoted":     {Type: "string"},
+			"scores":     {Type: "object", AdditionalProperties: &Schema{Type: "number", Format: "double"}},
+			"optional":   {AllOf: []*Schema{{Ref: "#/components/schemas/CentralityScores"}}, Nullable: true},
+			"optionalid": {Type: "string", Nullable: true},
+			"raw":        {Type: "string", Format: "byte"},
+			"Untagged":   {Type: "boolean"},
+			"recursive":  {Ref: "#/components/schemas/testRecursive"},
+		},
+	}, document.Components.Schemas["testDTO"])
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/testRecursive"}},
+		},
+	}, document.Components.Schemas["testRecursive"])
+
+	operation := document.Paths["/test"]["post"]
+	assert.Equal(t, "test", operation.OperationID)
+	assert.Equal(t, "#/components/schemas/testDTO", operation.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/BasicAPIResponse", operation.Responses["200"].Content["application/json"].Schema.Ref)
+	assert.Contains(t, operation.Responses, "400")
+}
+
+func TestGenerateRejectsInvalidEndpoints(t *testing.T) {
+	_, err := Generate(Info{}, []Endpoint{{Method: "GET", Path: "/test", Request: testDTO{}}})
+	assert.EqualError(t, err, "GET /test cannot have a request body")
+
+	_, err = Generate(Info{}, []Endpoint{{Method: "GET", Path: "/test"}, {Method: "get", Path: "/test"}})
+	assert.EqualError(t, err, "get /test is registered more than once")
+}
+
+func TestGeneratePathParameters(t *testing.T) {
+	document, err := Generate(Info{}, []Endpoint{{
+		Method:     "GET",
+		Path:       "/test/{crawlid}/{steamid}",
+		PathParams: map[string]string{"steamid": "a user", "crawlid": "a crawl"},
+	}})
+
+	assert.Nil(t, err)
+	operation := document.Paths["/test/{crawlid}/{steamid}"]["get"]
+	assert.Equal(t, "test", operation.OperationID)
+	assert.Equal(t, []Parameter{
+		{Name: "crawlid", In: "path", Description: "a crawl", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "steamid", In: "path", Description: "a user", Required: true, Schema: &Schema{Type: "string"}},
+	}, operation.Parameters)
+	assert.Contains(t, operation.Responses, "400")
+}
+
+func TestGenerateRejectsUndescribedPathParameters(t *testing.T) {
+	_, err := Generate(Info{}, []Endpoint{{Method: "GET", Path: "/test/{crawlid}"}})
+	assert.EqualError(t, err, "GET /test/{crawlid} has path parameters [crawlid] but describes 0")
+
+	_, err = Generate(Info{}, []Endpoint{{Method: "GET", Path: "/test/{crawlid}", PathParams: map[string]string{"steamid": "a user"}}})
+	assert.EqualError(t, err, "GET /test/{crawlid} does not describe path parameter crawlid")
+}
+
+func TestEveryEndpointHasASummary(t *testing.T) {
+	for _, endpoint := range Endpoints {
+		assert.NotEmpty(t, endpoint.Summary, endpoint.Path)
+	}
+}
+
+type testGeneric[T any] struct {
+	Value T `json:"value"`
+}
+
+func TestGenerateGenericTypeNames(t *testing.T) {
+	document, err := Generate(Info{}, nil, testGeneric[common.UsersGraphData]{}, testGeneric[[]int]{}, testGeneric[interface{}]{})
+
+	assert.Nil(t, err)
+	assert.Contains(t, document.Components.Schemas, "testGenericUsersGraphData")
+	assert.Contains(t, document.Components.Schemas, "testGenericInt")
+	assert.Contains(t, document.Components.Schemas, "testGenericInterface")
+	assert.Equal(t, "#/components/schemas/UsersGraphData", document.Components.Schemas["testGenericUsersGraphData"].Properties["value"].Ref)
+}
+
+func TestGenerateQualifiesCollidingNamesInAnyOrder(t *testing.T) {
+	for _, extra := range [][]interface{}{
+		{Response{}, common.Response{}},
+		{common.Response{}, Response{}},
+	} {
+		document, err := Generate(Info{}, nil, extra...)
+
+		assert.Nil(t, err)
+		assert.Contains(t, document.Components.Schemas, "openapiResponse")
+		assert.Contains(t, document.Components.Schemas, "commonResponse")
+		assert.NotContains(t, document.Components.Schemas, "Response")
+	}
+}
@@ -0,0 +1,320 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+)
+
+// ErrUnsupportedVersion is returned for documents written with a newer
+// schema than this version of common understands
+var ErrUnsupportedVersion = errors.New("unsupported schema version")
+
+// document is a decoded JSON object, numbers are kept as json.Number
+// so that large values such as steamIDs are not rounded
+type document map[string]interface{}
+
+// migration changes a document from one schema version to the next (up)
+// and back again (down). Only the wire format changes between versions,
+// the version key itself is set by migrate
+type migration struct {
+	up   func(document)
+	down func(document)
+}
+
+// migrations are the steps between versions of a type of document, the
+// migration at index i is from SchemaVersion(i+1) to SchemaVersion(i+2)
+type migrations []migration
+
+// noChange is used for documents whose format did not change in a version
+var noChange = migration{up: func(document) {}, down: func(document) {}}
+
+// graphInformationKeys are the keys of UsersGraphInformation
+// in SchemaVersion1 and their names in SchemaVersion2
+var graphInformationKeys = map[string]string{
+	"User":         "user",
+	"FromID":       "fromid",
+	"MaxLevel":     "maxlevel",
+	"CurrentLevel": "currentlevel",
+	"Centrality":   "centrality",
+}
+
+var usersGraphDataMigrations = migrations{
+	{
+		up: func(doc document) {
+			forEachGraphInformation(doc, func(user document) {
+				renameKeys(user, graphInformationKeys)
+			})
+		},
+		down: func(doc document) {
+			forEachGraphInformation(doc, func(user document) {
+				renameKeys(user, invert(graphInformationKeys))
+			})
+		},
+	},
+}
+
+var saveUserDTOMigrations = migrations{
+	{
+		up: func(doc document) {
+			renameKeys(doc, map[string]string{"orginalcrawltarget": "originalcrawltarget"})
+		},
+		down: func(doc document) {
+			renameKeys(doc, map[string]string{"originalcrawltarget": "orginalcrawltarget"})
+		},
+	},
+}
+
+var userDetailsMigrations = migrations{
+	{
+		up: func(doc document) {
+			renameKeys(doc, userDetailsKeys)
+		},
+		down: func(doc document) {
+			renameKeys(doc, invert(userDetailsKeys))
+		},
+	},
+}
+
+// userDetailsKeys are the keys of UserDetails in
+// SchemaVersion1 and their names in SchemaVersion2
+var userDetailsKeys = map[string]string{
+	"steamID":     "steamid",
+	"friendsList": "friendslist",
+}
+
+var (
+	crawlingStatusMigrations       = migrations{noChange}
+	userDocumentMigrations         = migrations{noChange}
+	gameInfoDocumentMigrations     = migrations{noChange}
+	saveCrawlingStatsDTOMigrations = migrations{noChange}
+)
+
+// Version returns the schema version of a JSON document,
+// documents without a version are SchemaVersion1
+func Version(b []byte) (int, error) {
+	versioned := struct {
+		SchemaVersion int `json:"schemaversion"`
+	}{}
+	if err := json.Unmarshal(b, &versioned); err != nil {
+		return 0, fmt.Errorf("could not read schema version: %w", err)
+	}
+	if versioned.SchemaVersion == 0 {
+		return common.SchemaVersion1, nil
+	}
+	return versioned.SchemaVersion, nil
+}
+
+// MigrateUsersGraphData rewrites a UsersGraphData JSON document in the
+// format of schema version to, which can be older than the document's
+// version so that services can still serve clients on older versions
+func MigrateUsersGraphData(b []byte, to int) ([]byte, error) {
+	return usersGraphDataMigrations.migrate(b, to)
+}
+
+// MigrateSaveUserDTO rewrites a SaveUserDTO JSON document in
+// the format of schema version to, see MigrateUsersGraphData
+func MigrateSaveUserDTO(b []byte, to int) ([]byte, error) {
+	return saveUserDTOMigrations.migrate(b, to)
+}
+
+// MigrateCrawlingStatus rewrites a CrawlingStatus JSON document in
+// the format of schema version to, see MigrateUsersGraphData
+func MigrateCrawlingStatus(b []byte, to int) ([]byte, error) {
+	return crawlingStatusMigrations.migrate(b, to)
+}
+
+// MigrateUserDetails rewrites a UserDetails JSON document in
+// the format of schema version to, see MigrateUsersGraphData
+func MigrateUserDetails(b []byte, to int) ([]byte, error) {
+	return userDetailsMigrations.migrate(b, to)
+}
+
+// MigrateUserDocument rewrites a UserDocument JSON document in
+// the format of schema version to, see MigrateUsersGraphData
+func MigrateUserDocument(b []byte, to int) ([]byte, error) {
+	return userDocumentMigrations.migrate(b, to)
+}
+
+// MigrateGameInfoDocument rewrites a GameInfoDocument JSON document
+// in the format of schema version to, see MigrateUsersGraphData
+func MigrateGameInfoDocument(b []byte, to int) ([]byte, error) {
+	return gameInfoDocumentMigrations.migrate(b, to)
+}
+
+// MigrateSaveCrawlingStatsDTO rewrites a SaveCrawlingStatsDTO JSON
+// document in the format of schema version to, see MigrateUsersGraphData
+func MigrateSaveCrawlingStatsDTO(b []byte, to int) ([]byte, error) {
+	return saveCrawlingStatsDTOMigrations.migrate(b, to)
+}
+
+// DecodeUsersGraphData decodes a UsersGraphData JSON document
+// of any supported schema version into the current version
+func DecodeUsersGraphData(b []byte) (common.UsersGraphData, error) {
+	data := common.UsersGraphData{}
+	if err := usersGraphDataMigrations.decode(b, &data); err != nil {
+		return common.UsersGraphData{}, err
+	}
+	data.SchemaVersion = common.CurrentSchemaVersion
+	return data, nil
+}
+
+// DecodeSaveUserDTO decodes a SaveUserDTO JSON document of
+// any supported schema version into the current version
+func DecodeSaveUserDTO(b []byte) (dtos.SaveUserDTO, error) {
+	dto := dtos.SaveUserDTO{}
+	if err := saveUserDTOMigrations.decode(b, &dto); err != nil {
+		return dtos.SaveUserDTO{}, err
+	}
+	dto.SchemaVersion = common.CurrentSchemaVersion
+	return dto, nil
+}
+
+// DecodeCrawlingStatus decodes a CrawlingStatus JSON document
+// of any supported schema version into the current version
+func DecodeCrawlingStatus(b []byte) (common.CrawlingStatus, error) {
+	status := common.CrawlingStatus{}
+	if err := crawlingStatusMigrations.decode(b, &status); err != nil {
+		return common.CrawlingStatus{}, err
+	}
+	status.SchemaVersion = common.CurrentSchemaVersion
+	return status, nil
+}
+
+// DecodeUserDetails decodes a UserDetails JSON document of
+// any supported schema version into the current version
+func DecodeUserDetails(b []byte) (common.UserDetails, error) {
+	details := common.UserDetails{}
+	if err := userDetailsMigrations.decode(b, &details); err != nil {
+		return common.UserDetails{}, err
+	}
+	details.SchemaVersion = common.CurrentSchemaVersion
+	return details, nil
+}
+
+// DecodeUserDocument decodes a UserDocument JSON document of
+// any supported schema version into the current version
+func DecodeUserDocument(b []byte) (common.UserDocument, error) {
+	user := common.UserDocument{}
+	if err := userDocumentMigrations.decode(b, &user); err != nil {
+		return common.UserDocument{}, err
+	}
+	user.SchemaVersion = common.CurrentSchemaVersion
+	return user, nil
+}
+
+// DecodeGameInfoDocument decodes a GameInfoDocument JSON document
+// of any supported schema version into the current version
+func DecodeGameInfoDocument(b []byte) (common.GameInfoDocument, error) {
+	game := common.GameInfoDocument{}
+	if err := gameInfoDocumentMigrations.decode(b, &game); err != nil {
+		return common.GameInfoDocument{}, err
+	}
+	game.SchemaVersion = common.CurrentSchemaVersion
+	return game, nil
+}
+
+// DecodeSaveCrawlingStatsDTO decodes a SaveCrawlingStatsDTO JSON
+// document of any supported schema version into the current version
+func DecodeSaveCrawlingStatsDTO(b []byte) (dtos.SaveCrawlingStatsDTO, error) {
+	dto := dtos.SaveCrawlingStatsDTO{}
+	if err := saveCrawlingStatsDTOMigrations.decode(b, &dto); err != nil {
+		return dtos.SaveCrawlingStatsDTO{}, err
+	}
+	dto.SchemaVersion = common.CurrentSchemaVersion
+	return dto, nil
+}
+
+func checkVersion(version int) error {
+	if version < common.SchemaVersion1 || version > common.CurrentSchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	return nil
+}
+
+func (m migrations) decode(b []byte, v interface{}) error {
+	version, err := Version(b)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(version); err != nil {
+		return err
+	}
+	if version != common.CurrentSchemaVersion {
+		if b, err = m.migrate(b, common.CurrentSchemaVersion); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (m migrations) migrate(b []byte, to int) ([]byte, error) {
+	from, err := Version(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range []int{from, to} {
+		if err := checkVersion(version); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	doc := document{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not parse document: %w", err)
+	}
+	for version := from; version < to; version++ {
+		m[version-1].up(doc)
+	}
+	for version := from; version > to; version-- {
+		m[version-2].down(doc)
+	}
+
+	if to == common.SchemaVersion1 {
+		delete(doc, "schemaversion")
+	} else {
+		doc["schemaversion"] = to
+	}
+	return json.Marshal(doc)
+}
+
+// renameKeys renames keys of a document, keys that are not present are
+// skipped and an existing key with the new name is replaced
+func renameKeys(doc document, names map[string]string) {
+	for oldName, newName := range names {
+		if value, exists := doc[oldName]; exists {
+			delete(doc, oldName)
+			doc[newName] = value
+		}
+	}
+}
+
+func invert(names map[string]string) map[string]string {
+	inverted := make(map[string]string, len(names))
+	for oldName, newName := range names {
+		inverted[newName] = oldName
+	}
+	return inverted
+}
+
+// forEachGraphInformation calls fn with the user details and each
+// friend detail of a UsersGraphData document
+func forEachGraphInformation(doc document, fn func(document)) {
+	asDocument := func(v interface{}) {
+		if user, ok := v.(map[string]interface{}); ok {
+			fn(user)
+		}
+	}
+	asDocument(doc["userdetails"])
+	if friends, ok := doc["frienddetails"].([]interface{}); ok {
+		for _, friend := range friends {
+			asDocument(friend)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	legacyUsersGraphData = `{
+		"userdetails": {
+			"User": {"accdetails": {"steamid": "76561197960287930", "personaname": "root", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": ["76561197960265731"], "gamesowned": [{"appid": 10, "playtime_forever": 32}], "insertiontime": 1640995200000},
+			"FromID": "",
+			"MaxLevel": 2,
+			"CurrentLevel": 0
+		},
+		"frienddetails": [{
+			"User": {"accdetails": {"steamid": "76561197960265731", "personaname": "alice", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": null, "gamesowned": null, "insertiontime": 0},
+			"FromID": "76561197960287930",
+			"MaxLevel": 2,
+			"CurrentLevel": 1,
+			"Centrality": {"degree": 1, "betweenness": 0, "closeness": 1, "pagerank": 0.5}
+		}],
+		"topgamedetails": [{"appid": 10, "name": "Counter-Strike"}]
+	}`
+	currentUsersGraphData = `{
+		"schemaversion": 2,
+		"userdetails": {
+			"user": {"accdetails": {"steamid": "76561197960287930", "personaname": "root", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": ["76561197960265731"], "gamesowned": [{"appid": 10, "playtime_forever": 32}], "insertiontime": 1640995200000},
+			"fromid": "",
+			"maxlevel": 2,
+			"currentlevel": 0
+		},
+		"frienddetails": [{
+			"user": {"accdetails": {"steamid": "76561197960265731", "personaname": "alice", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": null, "gamesowned": null, "insertiontime": 0},
+			"fromid": "76561197960287930",
+			"maxlevel": 2,
+			"currentlevel": 1,
+			"centrality": {"degree": 1, "betweenness": 0, "closeness": 1, "pagerank": 0.5}
+		}],
+		"topgamedetails": [{"appid": 10, "name": "Counter-Strike"}]
+	}`
+	legacyUserDetails  = `{"steamID": 76561197960287930, "friendsList": {"friends": []}}`
+	currentUserDetails = `{"schemaversion": 2, "steamid": 76561197960287930, "friendslist": {"friends": []}}`
+	legacySaveUserDTO  = `{"orginalcrawltarget": "76561197960287930", "crawlid": "abc", "currentlevel": 1, "maxlevel": 2, "user": {"accdetails": {"steamid": "76561197960265731", "personaname": "", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": null, "gamesowned": null, "insertiontime": 0}, "gamesownedfull": null}`
+	currentSaveUserDTO = `{"schemaversion": 2, "originalcrawltarget": "76561197960287930", "crawlid": "abc", "currentlevel": 1, "maxlevel": 2, "user": {"accdetails": {"steamid": "76561197960265731", "personaname": "", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": null, "gamesowned": null, "insertiontime": 0}, "gamesownedfull": null}`
+)
+
+func newTestUsersGraphData() common.UsersGraphData {
+	return common.UsersGraphData{
+		SchemaVersion: common.CurrentSchemaVersion,
+		UserDetails: common.UsersGraphInformation{
+			User: common.UserDocument{
+				AccDetails:    common.AccDetailsDocument{SteamID: "76561197960287930", Personaname: "root"},
+				FriendIDs:     []string{"76561197960265731"},
+				GamesOwned:    []common.GameOwnedDocument{{AppID: 10, Playtime_Forever: 32}},
+				InsertionTime: 1640995200000,
+			},
+			MaxLevel: 2,
+		},
+		FriendDetails: []common.UsersGraphInformation{
+			{
+				User:         common.UserDocument{AccDetails: common.AccDetailsDocument{SteamID: "76561197960265731", Personaname: "alice"}},
+				FromID:       "76561197960287930",
+				MaxLevel:     2,
+				CurrentLevel: 1,
+				Centrality:   &common.CentralityScores{Degree: 1, Closeness: 1, PageRank: 0.5},
+			},
+		},
+		TopGameDetails: []common.BareGameInfo{{AppID: 10, Name: "Counter-Strike"}},
+	}
+}
+
+func newTestSaveUserDTO() dtos.SaveUserDTO {
+	return dtos.SaveUserDTO{
+		SchemaVersion:       common.CurrentSchemaVersion,
+		OriginalCrawlTarget: "76561197960287930",
+		CrawlID:             "abc",
+		CurrentLevel:        1,
+		MaxLevel:            2,
+		User:                common.UserDocument{AccDetails: common.AccDetailsDocument{SteamID: "76561197960265731"}},
+	}
+}
+
+func TestWireFormatOfUsersGraphData(t *testing.T) {
+	encoded, err := json.Marshal(newTestUsersGraphData())
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentUsersGraphData, string(encoded))
+}
+
+func TestWireFormatOfSaveUserDTO(t *testing.T) {
+	encoded, err := json.Marshal(newTestSaveUserDTO())
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentSaveUserDTO, string(encoded))
+}
+
+func TestWireFormatOfCrawlingStatus(t *testing.T) {
+	encoded, err := json.Marshal(common.CrawlingStatus{SchemaVersion: 2, TimeStarted: 1640995200, CrawlID: "abc", OriginalCrawlTarget: "76561197960287930", MaxLevel: 2, TotalUsersToCrawl: 10, UsersCrawled: 3})
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "timestarted": 1640995200, "crawlid": "abc", "originalcrawltarget": "76561197960287930", "maxlevel": 2, "totaluserstocrawl": 10, "userscrawled": 3}`, string(encoded))
+}
+
+func TestWireFormatOfUserDetails(t *testing.T) {
+	encoded, err := json.Marshal(common.UserDetails{SchemaVersion: 2, SteamID: 76561197960287930, Friends: common.Friendslist{Friends: []common.Friend{}}})
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentUserDetails, string(encoded))
+}
+
+func TestWireFormatOfUserDocument(t *testing.T) {
+	encoded, err := json.Marshal(common.UserDocument{SchemaVersion: 2, FriendIDs: []string{"76561197960265731"}, InsertionTime: 1640995200000})
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "accdetails": {"steamid": "", "personaname": "", "profileurl": "", "avatar": "", "timecreated": 0, "loccountrycode": ""}, "friendids": ["76561197960265731"], "gamesowned": null, "insertiontime": 1640995200000}`, string(encoded))
+}
+
+func TestWireFormatOfGameInfoDocument(t *testing.T) {
+	encoded, err := json.Marshal(common.GameInfoDocument{SchemaVersion: 2, AppID: 10, Name: "Counter-Strike"})
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "appid": 10, "name": "Counter-Strike", "imgiconurl": "", "imglogourl": ""}`, string(encoded))
+}
+
+func TestWireFormatOfSaveCrawlingStatsDTO(t *testing.T) {
+	encoded, err := json.Marshal(dtos.SaveCrawlingStatsDTO{SchemaVersion: 2, CurrentLevel: 1, CrawlingStatus: common.CrawlingStatus{CrawlID: "abc"}})
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "currentlevel": 1, "crawlingstatus": {"timestarted": 0, "crawlid": "abc", "originalcrawltarget": "", "maxlevel": 0, "totaluserstocrawl": 0, "userscrawled": 0}}`, string(encoded))
+}
+
+func TestVersion(t *testing.T) {
+	tests := map[string]int{
+		legacyUsersGraphData:   common.SchemaVersion1,
+		currentUsersGraphData:  common.SchemaVersion2,
+		`{"schemaversion": 7}`: 7,
+	}
+	for document, expected := range tests {
+		version, err := Version([]byte(document))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, version)
+	}
+
+	_, err := Version([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestMigrateUsersGraphData(t *testing.T) {
+	upgraded, err := MigrateUsersGraphData([]byte(legacyUsersGraphData), common.SchemaVersion2)
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentUsersGraphData, string(upgraded))
+
+	downgraded, err := MigrateUsersGraphData([]byte(currentUsersGraphData), common.SchemaVersion1)
+	assert.Nil(t, err)
+	assert.JSONEq(t, legacyUsersGraphData, string(downgraded))
+}
+
+func TestMigrateSaveUserDTO(t *testing.T) {
+	upgraded, err := MigrateSaveUserDTO([]byte(legacySaveUserDTO), common.SchemaVersion2)
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentSaveUserDTO, string(upgraded))
+
+	downgraded, err := MigrateSaveUserDTO([]byte(currentSaveUserDTO), common.SchemaVersion1)
+	assert.Nil(t, err)
+	assert.JSONEq(t, legacySaveUserDTO, string(downgraded))
+}
+
+func TestMigrateCrawlingStatus(t *testing.T) {
+	upgraded, err := MigrateCrawlingStatus([]byte(`{"crawlid": "abc", "maxlevel": 2}`), common.SchemaVersion2)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "crawlid": "abc", "maxlevel": 2}`, string(upgraded))
+}
+
+func TestMigrateUserDetails(t *testing.T) {
+	upgraded, err := MigrateUserDetails([]byte(legacyUserDetails), common.SchemaVersion2)
+	assert.Nil(t, err)
+	assert.JSONEq(t, currentUserDetails, string(upgraded))
+
+	downgraded, err := MigrateUserDetails([]byte(currentUserDetails), common.SchemaVersion1)
+	assert.Nil(t, err)
+	assert.JSONEq(t, legacyUserDetails, string(downgraded))
+}
+
+func TestMigrateKeepsLargeNumbers(t *testing.T) {
+	migrated, err := MigrateUsersGraphData([]byte(`{"userdetails": {"User": {"insertiontime": 9007199254740993}}}`), common.SchemaVersion2)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"schemaversion": 2, "userdetails": {"user": {"insertiontime": 9007199254740993}}}`, string(migrated))
+}
+
+func TestMigrateRejectsUnsupportedVersions(t *testing.T) {
+	_, err := MigrateUsersGraphData([]byte(`{"schemaversion": 99}`), common.SchemaVersion1)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = MigrateUsersGraphData([]byte(legacyUsersGraphData), 99)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestDecodeUsersGraphDataOfEitherVersion(t *testing.T) {
+	for _, document := range []string{legacyUsersGraphData, currentUsersGraphData} {
+		data, err := DecodeUsersGraphData([]byte(document))
+
+		assert.Nil(t, err)
+		assert.Equal(t, newTestUsersGraphData(), data)
+	}
+}
+
+func TestDecodeSaveUserDTOOfEitherVersion(t *testing.T) {
+	for _, document := range []string{legacySaveUserDTO, currentSaveUserDTO} {
+		dto, err := DecodeSaveUserDTO([]byte(document))
+
+		assert.Nil(t, err)
+		assert.Equal(t, newTestSaveUserDTO(), dto)
+	}
+}
+
+func TestDecodeCrawlingStatus(t *testing.T) {
+	status, err := DecodeCrawlingStatus([]byte(`{"crawlid": "abc"}`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, common.CrawlingStatus{SchemaVersion: common.CurrentSchemaVersion, CrawlID: "abc"}, status)
+}
+
+func TestDecodeUserDetailsOfEitherVersion(t *testing.T) {
+	for _, document := range []string{legacyUserDetails, currentUserDetails} {
+		details, err := DecodeUserDetails([]byte(document))
+
+		assert.Nil(t, err)
+		assert.Equal(t, common.UserDetails{SchemaVersion: common.CurrentSchemaVersion, SteamID: 76561197960287930, Friends: common.Friendslist{Friends: []common.Friend{}}}, details)
+	}
+}
+
+func TestDecodeUnchangedDocuments(t *testing.T) {
+	user, err := DecodeUserDocument([]byte(`{"friendids": ["76561197960265731"]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, common.UserDocument{SchemaVersion: common.CurrentSchemaVersion, FriendIDs: []string{"76561197960265731"}}, user)
+
+	game, err := DecodeGameInfoDocument([]byte(`{"appid": 10}`))
+	assert.Nil(t, err)
+	assert.Equal(t, common.GameInfoDocument{SchemaVersion: common.CurrentSchemaVersion, AppID: 10}, game)
+
+	dto, err := DecodeSaveCrawlingStatsDTO([]byte(`{"currentlevel": 1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, dtos.SaveCrawlingStatsDTO{SchemaVersion: common.CurrentSchemaVersion, CurrentLevel: 1}, dto)
+}
+
+func TestDecodeRejectsNewerVersions(t *testing.T) {
+	_, err := DecodeUsersGraphData([]byte(`{"schemaversion": 3}`))
+
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	assert.EqualError(t, err, "unsupported schema version: 3")
+}
+
+func TestSaveUserDTOAcceptsLegacyOriginalCrawlTarget(t *testing.T) {
+	dto := dtos.SaveUserDTO{}
+
+	err := json.Unmarshal([]byte(legacySaveUserDTO), &dto)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "76561197960287930", dto.OriginalCrawlTarget)
+	assert.Equal(t, "abc", dto.CrawlID)
+}
+
+func TestDecodeSaveUserDTOIgnoresUnknownFields(t *testing.T) {
+	document := strings.Replace(currentSaveUserDTO, `"crawlid"`, `"addedlater": true, "crawlid"`, 1)
+
+	dto, err := DecodeSaveUserDTO([]byte(document))
+
+	assert.Nil(t, err)
+	assert.Equal(t, newTestSaveUserDTO(), dto)
+}
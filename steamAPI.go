@@ -33,8 +33,9 @@ type Player struct {
 }
 
 type UserDetails struct {
-	SteamID int64       `json:"steamID"`
-	Friends Friendslist `json:"friendsList"`
+	SchemaVersion int         `json:"schemaversion,omitempty"`
+	SteamID       int64       `json:"steamid"`
+	Friends       Friendslist `json:"friendslist"`
 }
 
 // FriendsList holds all friends for a given user
@@ -0,0 +1,268 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// ErrClosed is returned when writing to a closed Writer
+var ErrClosed = errors.New("writer is closed")
+
+// Writer writes a crawl one user at a time so the whole
+// crawl never has to be held in memory
+type Writer interface {
+	WriteSchemaVersion(version int) error
+	WriteUserDetails(user common.UsersGraphInformation) error
+	WriteFriendDetails(user common.UsersGraphInformation) error
+	WriteTopGameDetails(games []common.BareGameInfo) error
+	// Flush sends everything written so far, flushing the
+	// underlying writer too if it is an http.Flusher
+	Flush() error
+	// Close finishes the crawl and flushes it, it does
+	// not close the underlying writer
+	Close() error
+}
+
+// WriteUsersGraphData writes an entire crawl with w and closes it
+func WriteUsersGraphData(w Writer, data common.UsersGraphData) error {
+	if data.SchemaVersion != 0 {
+		if err := w.WriteSchemaVersion(data.SchemaVersion); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteUserDetails(data.UserDetails); err != nil {
+		return err
+	}
+	for _, user := range data.FriendDetails {
+		if err := w.WriteFriendDetails(user); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteTopGameDetails(data.TopGameDetails); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// DocumentWriter writes a crawl as a single JSON document in the same
+// format as json.Marshal of a UsersGraphData. Its parts can be written in
+// any order but friend details must all be written together
+type DocumentWriter struct {
+	w       io.Writer
+	buf     *bufio.Writer
+	written map[string]bool
+	// inFriends is set while the friend details array is open
+	inFriends bool
+	closed    bool
+}
+
+// NewDocumentWriter creates a DocumentWriter writing to w
+func NewDocumentWriter(w io.Writer) *DocumentWriter {
+	return &DocumentWriter{
+		w:       w,
+		buf:     bufio.NewWriter(w),
+		written: make(map[string]bool),
+	}
+}
+
+func (dw *DocumentWriter) value(v interface{}) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = dw.buf.Write(encoded)
+	return err
+}
+
+// key starts a new key of the document, finishing the friend details if
+// they were being written. Each key can only be written once
+func (dw *DocumentWriter) key(name string) error {
+	if dw.closed {
+		return ErrClosed
+	}
+	if dw.written[name] {
+		return fmt.Errorf("%s has already been written", name)
+	}
+	if dw.inFriends {
+		dw.inFriends = false
+		dw.buf.WriteByte(']')
+	}
+
+	if len(dw.written) == 0 {
+		dw.buf.WriteByte('{')
+	} else {
+		dw.buf.WriteByte(',')
+	}
+	dw.written[name] = true
+	_, err := fmt.Fprintf(dw.buf, "%q:", name)
+	return err
+}
+
+// WriteSchemaVersion writes the schema version of the crawl
+func (dw *DocumentWriter) WriteSchemaVersion(version int) error {
+	if err := dw.key("schemaversion"); err != nil {
+		return err
+	}
+	return dw.value(version)
+}
+
+// WriteUserDetails writes the crawl target
+func (dw *DocumentWriter) WriteUserDetails(user common.UsersGraphInformation) error {
+	if err := dw.key("userdetails"); err != nil {
+		return err
+	}
+	return dw.value(user)
+}
+
+// WriteFriendDetails writes the next user found in the crawl
+func (dw *DocumentWriter) WriteFriendDetails(user common.UsersGraphInformation) error {
+	if !dw.inFriends {
+		if err := dw.key("frienddetails"); err != nil {
+			return err
+		}
+		dw.inFriends = true
+		dw.buf.WriteByte('[')
+	} else {
+		dw.buf.WriteByte(',')
+	}
+	return dw.value(user)
+}
+
+// WriteTopGameDetails writes the most popular games of the crawl
+func (dw *DocumentWriter) WriteTopGameDetails(games []common.BareGameInfo) error {
+	if err := dw.key("topgamedetails"); err != nil {
+		return err
+	}
+	if games == nil {
+		games = []common.BareGameInfo{}
+	}
+	return dw.value(games)
+}
+
+// Flush sends everything written so far
+func (dw *DocumentWriter) Flush() error {
+	return flush(dw.w, dw.buf)
+}
+
+// Close finishes the document, writing empty friend and top game details
+// if none were written. It is an error to close a document without user
+// details as it would not describe a crawl
+func (dw *DocumentWriter) Close() error {
+	if dw.closed {
+		return nil
+	}
+	if !dw.written["userdetails"] {
+		return errors.New("no user details have been written")
+	}
+	if !dw.written["frienddetails"] {
+		if err := dw.key("frienddetails"); err != nil {
+			return err
+		}
+		dw.buf.WriteString("[]")
+	}
+	if !dw.written["topgamedetails"] {
+		if err := dw.WriteTopGameDetails(nil); err != nil {
+			return err
+		}
+	}
+	if dw.inFriends {
+		dw.inFriends = false
+		dw.buf.WriteByte(']')
+	}
+	dw.closed = true
+	dw.buf.WriteString("}\n")
+	return dw.Flush()
+}
+
+func flush(w io.Writer, buf *bufio.Writer) error {
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// FriendFunc is called with each friend detail as it is read, returning
+// an error stops reading and the error is returned by the reader
+type FriendFunc func(user common.UsersGraphInformation) error
+
+// ReadDocument reads a crawl written as a single JSON document, such as by
+// DocumentWriter or json.Marshal. Friend details are passed to fn one at a
+// time instead of being kept, so the returned crawl has no FriendDetails
+func ReadDocument(r io.Reader, fn FriendFunc) (common.UsersGraphData, error) {
+	decoder := json.NewDecoder(r)
+	data := common.UsersGraphData{}
+	if err := expectDelim(decoder, '{'); err != nil {
+		return common.UsersGraphData{}, err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return common.UsersGraphData{}, fmt.Errorf("could not read crawl: %w", err)
+		}
+		key, _ := token.(string)
+		switch {
+		case strings.EqualFold(key, "schemaversion"):
+			err = decoder.Decode(&data.SchemaVersion)
+		case strings.EqualFold(key, "userdetails"):
+			err = decoder.Decode(&data.UserDetails)
+		case strings.EqualFold(key, "topgamedetails"):
+			err = decoder.Decode(&data.TopGameDetails)
+		case strings.EqualFold(key, "frienddetails"):
+			err = readFriendDetails(decoder, fn)
+		default:
+			err = decoder.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return common.UsersGraphData{}, fmt.Errorf("could not read %s: %w", key, err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return common.UsersGraphData{}, err
+	}
+	return data, nil
+}
+
+func readFriendDetails(decoder *json.Decoder, fn FriendFunc) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array but found %v", token)
+	}
+	for decoder.More() {
+		user := common.UsersGraphInformation{}
+		if err := decoder.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, expected json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("could not read crawl: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("could not read crawl: expected %v but found %v", expected, token)
+	}
+	return nil
+}
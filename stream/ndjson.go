@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/neosteamfriendgraphing/common"
+)
+
+// ContentTypeNDJSON is the media type of crawls written by NDJSONWriter
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// Kinds of records in an NDJSON crawl
+const (
+	KindSchemaVersion  = "schemaversion"
+	KindUserDetails    = "userdetails"
+	KindFriendDetails  = "frienddetails"
+	KindTopGameDetails = "topgamedetails"
+)
+
+// maxRecordSize is the largest single line NDJSONReader accepts, a user
+// with thousands of friends and games is still well under this
+const maxRecordSize = 16 * 1024 * 1024
+
+// Record is a single line of an NDJSON crawl. User is set for user and
+// friend details, Games for top game details and Version for the
+// schema version
+type Record struct {
+	Kind    string                        `json:"kind"`
+	Version int                           `json:"version,omitempty"`
+	User    *common.UsersGraphInformation `json:"user,omitempty"`
+	Games   []common.BareGameInfo         `json:"games,omitempty"`
+}
+
+// NDJSONWriter writes a crawl as newline delimited JSON, one Record per
+// line, so each line can be parsed on its own as it arrives
+type NDJSONWriter struct {
+	w      io.Writer
+	buf    *bufio.Writer
+	closed bool
+}
+
+// NewNDJSONWriter creates an NDJSONWriter writing to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		w:   w,
+		buf: bufio.NewWriter(w),
+	}
+}
+
+func (nw *NDJSONWriter) write(record Record) error {
+	if nw.closed {
+		return ErrClosed
+	}
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	nw.buf.Write(encoded)
+	return nw.buf.WriteByte('\n')
+}
+
+// WriteSchemaVersion writes the schema version of the crawl
+func (nw *NDJSONWriter) WriteSchemaVersion(version int) error {
+	return nw.write(Record{Kind: KindSchemaVersion, Version: version})
+}
+
+// WriteUserDetails writes the crawl target
+func (nw *NDJSONWriter) WriteUserDetails(user common.UsersGraphInformation) error {
+	return nw.write(Record{Kind: KindUserDetails, User: &user})
+}
+
+// WriteFriendDetails writes the next user found in the crawl
+func (nw *NDJSONWriter) WriteFriendDetails(user common.UsersGraphInformation) error {
+	return nw.write(Record{Kind: KindFriendDetails, User: &user})
+}
+
+// WriteTopGameDetails writes the most popular games of the crawl
+func (nw *NDJSONWriter) WriteTopGameDetails(games []common.BareGameInfo) error {
+	return nw.write(Record{Kind: KindTopGameDetails, Games: games})
+}
+
+// Flush sends everything written so far
+func (nw *NDJSONWriter) Flush() error {
+	return flush(nw.w, nw.buf)
+}
+
+// Close flushes the crawl, nothing can be written after
+func (nw *NDJSONWriter) Close() error {
+	if nw.closed {
+		return nil
+	}
+	nw.closed = true
+	return nw.Flush()
+}
+
+// NDJSONReader reads the records of an NDJSON crawl one at a time
+type NDJSONReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONReader creates an NDJSONReader reading from r
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	return &NDJSONReader{scanner: scanner}
+}
+
+// Next returns the next record, skipping blank lines. io.EOF is
+// returned once every record has been read
+func (nr *NDJSONReader) Next() (Record, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		line := nr.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		record := Record{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return Record{}, fmt.Errorf("could not parse line %d: %w", nr.line, err)
+		}
+		switch record.Kind {
+		case KindUserDetails, KindFriendDetails:
+			if record.User == nil {
+				return Record{}, fmt.Errorf("line %d is %s without a user", nr.line, record.Kind)
+			}
+		case KindSchemaVersion, KindTopGameDetails:
+		default:
+			return Record{}, fmt.Errorf("line %d has unknown kind %q", nr.line, record.Kind)
+		}
+		return record, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return Record{}, fmt.Errorf("could not read line %d: %w", nr.line+1, err)
+	}
+	return Record{}, io.EOF
+}
+
+// ReadNDJSON reads an NDJSON crawl, passing friend details to fn
+// one at a time in the same way as ReadDocument
+func ReadNDJSON(r io.Reader, fn FriendFunc) (common.UsersGraphData, error) {
+	reader := NewNDJSONReader(r)
+	data := common.UsersGraphData{}
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return data, nil
+		}
+		if err != nil {
+			return common.UsersGraphData{}, err
+		}
+
+		switch record.Kind {
+		case KindSchemaVersion:
+			data.SchemaVersion = record.Version
+		case KindUserDetails:
+			data.UserDetails = *record.User
+		case KindFriendDetails:
+			if err := fn(*record.User); err != nil {
+				return common.UsersGraphData{}, err
+			}
+		case KindTopGameDetails:
+			data.TopGameDetails = record.Games
+			if data.TopGameDetails == nil {
+				data.TopGameDetails = []common.BareGameInfo{}
+			}
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUser(steamID string, level int, friendIDs ...string) common.UsersGraphInformation {
+	return common.UsersGraphInformation{
+		User: common.UserDocument{
+			AccDetails: common.AccDetailsDocument{
+				SteamID:     steamID,
+				Personaname: steamID + "name",
+			},
+			FriendIDs:  friendIDs,
+			GamesOwned: []common.GameOwnedDocument{{AppID: 10, Playtime_Forever: 100}},
+		},
+		MaxLevel:     2,
+		CurrentLevel: level,
+	}
+}
+
+func newTestCrawl() common.UsersGraphData {
+	return common.UsersGraphData{
+		SchemaVersion: common.CurrentSchemaVersion,
+		UserDetails:   newTestUser("root", 0, "alice", "bob"),
+		FriendDetails: []common.UsersGraphInformation{
+			newTestUser("alice", 1, "root"),
+			newTestUser("bob", 1, "root"),
+		},
+		TopGameDetails: []common.BareGameInfo{{AppID: 10, Name: "Counter-Strike"}},
+	}
+}
+
+// collect returns a FriendFunc that appends every friend to friends
+func collect(friends *[]common.UsersGraphInformation) FriendFunc {
+	return func(user common.UsersGraphInformation) error {
+		*friends = append(*friends, user)
+		return nil
+	}
+}
+
+func TestDocumentWriterMatchesJSON(t *testing.T) {
+	crawl := newTestCrawl()
+	expected, err := json.Marshal(crawl)
+	assert.Nil(t, err)
+	buf := bytes.Buffer{}
+
+	err = WriteUsersGraphData(NewDocumentWriter(&buf), crawl)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestDocumentWriterInAnyOrder(t *testing.T) {
+	crawl := newTestCrawl()
+	buf := bytes.Buffer{}
+	dw := NewDocumentWriter(&buf)
+
+	assert.Nil(t, dw.WriteFriendDetails(crawl.FriendDetails[0]))
+	assert.Nil(t, dw.WriteFriendDetails(crawl.FriendDetails[1]))
+	assert.Nil(t, dw.WriteTopGameDetails(crawl.TopGameDetails))
+	assert.Nil(t, dw.WriteUserDetails(crawl.UserDetails))
+	assert.Nil(t, dw.WriteSchemaVersion(crawl.SchemaVersion))
+	assert.Nil(t, dw.Close())
+
+	decoded := common.UsersGraphData{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, crawl, decoded)
+}
+
+func TestDocumentWriterWithoutFriends(t *testing.T) {
+	buf := bytes.Buffer{}
+	dw := NewDocumentWriter(&buf)
+
+	assert.Nil(t, dw.WriteUserDetails(newTestUser("root", 0)))
+	assert.Nil(t, dw.Close())
+
+	decoded := common.UsersGraphData{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, []common.UsersGraphInformation{}, decoded.FriendDetails)
+	assert.Equal(t, []common.BareGameInfo{}, decoded.TopGameDetails)
+}
+
+func TestDocumentWriterRejectsInvalidWrites(t *testing.T) {
+	dw := NewDocumentWriter(io.Discard)
+	assert.EqualError(t, dw.Close(), "no user details have been written")
+
+	assert.Nil(t, dw.WriteFriendDetails(newTestUser("alice", 1)))
+	assert.Nil(t, dw.WriteUserDetails(newTestUser("root", 0)))
+	assert.EqualError(t, dw.WriteFriendDetails(newTestUser("bob", 1)), "frienddetails has already been written")
+	assert.EqualError(t, dw.WriteUserDetails(newTestUser("root", 0)), "userdetails has already been written")
+
+	assert.Nil(t, dw.Close())
+	assert.ErrorIs(t, dw.WriteTopGameDetails(nil), ErrClosed)
+}
+
+func TestReadDocumentRoundTrips(t *testing.T) {
+	crawl := newTestCrawl()
+	buf := bytes.Buffer{}
+	assert.Nil(t, WriteUsersGraphData(NewDocumentWriter(&buf), crawl))
+
+	friends := []common.UsersGraphInformation{}
+	data, err := ReadDocument(&buf, collect(&friends))
+
+	assert.Nil(t, err)
+	assert.Equal(t, crawl.FriendDetails, friends)
+	data.FriendDetails = friends
+	assert.Equal(t, crawl, data)
+}
+
+func TestReadDocumentOfOtherJSON(t *testing.T) {
+	input := `{"TopGameDetails": [], "status": "success", "FriendDetails": null, "UserDetails": {"User": {"accdetails": {"steamid": "root"}}}}`
+
+	friends := []common.UsersGraphInformation{}
+	data, err := ReadDocument(strings.NewReader(input), collect(&friends))
+
+	assert.Nil(t, err)
+	assert.Empty(t, friends)
+	assert.Equal(t, "root", data.UserDetails.User.AccDetails.SteamID)
+	assert.Equal(t, []common.BareGameInfo{}, data.TopGameDetails)
+}
+
+func TestReadDocumentStopsOnError(t *testing.T) {
+	buf := bytes.Buffer{}
+	assert.Nil(t, WriteUsersGraphData(NewDocumentWriter(&buf), newTestCrawl()))
+	stop := errors.New("stop")
+	calls := 0
+
+	_, err := ReadDocument(&buf, func(user common.UsersGraphInformation) error {
+		calls++
+		return stop
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestReadDocumentRejectsInvalidJSON(t *testing.T) {
+	for _, input := range []string{``, `[]`, `{"frienddetails": {}}`, `{"frienddetails": [{"User": 1}]}`, `{"userdetails": {}`} {
+		_, err := ReadDocument(strings.NewReader(input), collect(&[]common.UsersGraphInformation{}))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestNDJSONRoundTrips(t *testing.T) {
+	crawl := newTestCrawl()
+	buf := bytes.Buffer{}
+	assert.Nil(t, WriteUsersGraphData(NewNDJSONWriter(&buf), crawl))
+	assert.Equal(t, 5, strings.Count(buf.String(), "\n"))
+
+	friends := []common.UsersGraphInformation{}
+	data, err := ReadNDJSON(&buf, collect(&friends))
+
+	assert.Nil(t, err)
+	data.FriendDetails = friends
+	assert.Equal(t, crawl, data)
+}
+
+func TestNDJSONReaderNext(t *testing.T) {
+	input := `{"kind":"userdetails","user":{"User":{"accdetails":{"steamid":"root"}}}}
+
+{"kind":"topgamedetails"}
+`
+	reader := NewNDJSONReader(strings.NewReader(input))
+
+	record, err := reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, KindUserDetails, record.Kind)
+	assert.Equal(t, "root", record.User.User.AccDetails.SteamID)
+	record, err = reader.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, KindTopGameDetails, record.Kind)
+	_, err = reader.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNDJSONReaderRejectsInvalidRecords(t *testing.T) {
+	tests := map[string]string{
+		`{"kind":"frienddetails"}`:        "line 1 is frienddetails without a user",
+		"\n" + `{"kind":"something"}`:     `line 2 has unknown kind "something"`,
+		`{"kind":"userdetails","user":1}`: "could not parse line 1: json: cannot unmarshal number into Go struct field Record.user of type common.UsersGraphInformation",
+	}
+	for input, expected := range tests {
+		_, err := ReadNDJSON(strings.NewReader(input), collect(&[]common.UsersGraphInformation{}))
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestWritersFlushToHTTPClients(t *testing.T) {
+	for name, newWriter := range map[string]func(io.Writer) Writer{
+		"document": func(w io.Writer) Writer { return NewDocumentWriter(w) },
+		"ndjson":   func(w io.Writer) Writer { return NewNDJSONWriter(w) },
+	} {
+		received := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := newWriter(w)
+			assert.Nil(t, writer.WriteUserDetails(newTestUser("root", 0)))
+			assert.Nil(t, writer.Flush())
+			// The client must see the user details before the crawl is finished
+			<-received
+			assert.Nil(t, writer.WriteFriendDetails(newTestUser("alice", 1)))
+			assert.Nil(t, writer.Close())
+		}))
+
+		res, err := http.Get(server.URL)
+		assert.Nil(t, err, name)
+		firstChunk := make([]byte, 64)
+		n, err := res.Body.Read(firstChunk)
+		assert.Nil(t, err, name)
+		assert.Contains(t, string(firstChunk[:n]), "userdetails", name)
+		close(received)
+
+		rest, err := io.ReadAll(res.Body)
+		assert.Nil(t, err, name)
+		assert.Contains(t, string(rest), "alice", name)
+		res.Body.Close()
+		server.Close()
+	}
+}
+
+func BenchmarkDocumentWriter(b *testing.B) {
+	user := newTestUser("root", 1, "alice", "bob")
+	for i := 0; i < b.N; i++ {
+		dw := NewDocumentWriter(io.Discard)
+		dw.WriteUserDetails(user)
+		for f := 0; f < 1000; f++ {
+			user.User.AccDetails.SteamID = fmt.Sprint(f)
+			dw.WriteFriendDetails(user)
+		}
+		dw.Close()
+	}
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/neosteamfriendgraphing/common"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerOption configures a logger created by NewLogger
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	level       *zap.AtomicLevel
+	encoding    string
+	sampling    *zap.SamplingConfig
+	samplingSet bool
+	sinks       []string
+	development bool
+	zapOptions  []zap.Option
+}
+
+// WithLevel sets the minimum level that will be logged. Defaults to info,
+// or debug in development mode
+func WithLevel(level zapcore.Level) LoggerOption {
+	return func(o *loggerOptions) {
+		atomicLevel := zap.NewAtomicLevelAt(level)
+		o.level = &atomicLevel
+	}
+}
+
+// WithAtomicLevel uses an existing AtomicLevel so that the level can be
+// shared between several loggers and changed at runtime
+func WithAtomicLevel(level zap.AtomicLevel) LoggerOption {
+	return func(o *loggerOptions) {
+		o.level = &level
+	}
+}
+
+// WithEncoding sets the log encoding, either "json" or "console"
+func WithEncoding(encoding string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.encoding = encoding
+	}
+}
+
+// WithSampling logs the first initial entries with the same level and message
+// each second and every thereafter-th entry after that
+func WithSampling(initial, thereafter int) LoggerOption {
+	return func(o *loggerOptions) {
+		o.sampling = &zap.SamplingConfig{
+			Initial:    initial,
+			Thereafter: thereafter,
+		}
+		o.samplingSet = true
+	}
+}
+
+// WithoutSampling disables sampling so that every entry is written
+func WithoutSampling() LoggerOption {
+	return func(o *loggerOptions) {
+		o.sampling = nil
+		o.samplingSet = true
+	}
+}
+
+// WithSinks adds output paths (files or zap sink URLs) that logs are
+// written to alongside stdout and LOG_PATH
+func WithSinks(paths ...string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.sinks = append(o.sinks, paths...)
+	}
+}
+
+// WithDevelopment switches to zap's development defaults: debug level,
+// console encoding, no sampling and more liberal stacktraces. Explicitly
+// set options still take priority
+func WithDevelopment() LoggerOption {
+	return func(o *loggerOptions) {
+		o.development = true
+	}
+}
+
+// WithZapOptions passes options straight through to the zap logger
+func WithZapOptions(opts ...zap.Option) LoggerOption {
+	return func(o *loggerOptions) {
+		o.zapOptions = append(o.zapOptions, opts...)
+	}
+}
+
+// NewLogger builds a zap logger with the default logging fields attached. The
+// returned AtomicLevel can be used to change the level at runtime, see
+// LogLevelHandler
+func NewLogger(logFieldsConfig common.LoggingFields, opts ...LoggerOption) (*zap.Logger, zap.AtomicLevel, error) {
+	options := loggerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	c := zap.NewProductionConfig()
+	if options.development {
+		c = zap.NewDevelopmentConfig()
+	}
+	if options.level != nil {
+		c.Level = *options.level
+	}
+	if options.encoding != "" {
+		if options.encoding != "json" && options.encoding != "console" {
+			return nil, zap.AtomicLevel{}, MakeErr(fmt.Errorf("unknown log encoding: %q", options.encoding))
+		}
+		c.Encoding = options.encoding
+	}
+	if options.samplingSet {
+		c.Sampling = options.sampling
+	}
+
+	c.OutputPaths = []string{"stdout"}
+	for _, path := range logFieldsConfig.LogPaths {
+		if path == "" || path == "stdout" {
+			continue
+		}
+		// Make sure the logfile exists before zap opens it
+		logFile, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0666)
+		if err != nil {
+			return nil, zap.AtomicLevel{}, MakeErr(err, fmt.Sprintf("could not create logfile %s", path))
+		}
+		logFile.Close()
+		c.OutputPaths = append(c.OutputPaths, path)
+	}
+	c.OutputPaths = append(c.OutputPaths, options.sinks...)
+
+	globalLogFields := make(map[string]interface{})
+	globalLogFields["nodeName"] = logFieldsConfig.NodeName
+	globalLogFields["nodeDC"] = logFieldsConfig.NodeDC
+	globalLogFields["nodeIPV4"] = logFieldsConfig.NodeIPV4
+	globalLogFields["service"] = logFieldsConfig.Service
+	globalLogFields["version"] = logFieldsConfig.Version
+	globalLogFields["commit"] = logFieldsConfig.Commit
+	c.InitialFields = globalLogFields
+
+	log, err := c.Build(options.zapOptions...)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, MakeErr(err, "could not build logger")
+	}
+	return log, c.Level, nil
+}
+
+// LogLevelHandler returns a handler that reports the current level on GET
+// and changes it on PUT with a body such as {"level":"debug"}
+func LogLevelHandler(level zap.AtomicLevel) http.Handler {
+	return level
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestLoggingFields(t *testing.T) common.LoggingFields {
+	return common.LoggingFields{
+		NodeName: "testNode",
+		NodeDC:   "testDC",
+		LogPaths: []string{"stdout", filepath.Join(t.TempDir(), "test.log")},
+		NodeIPV4: "127.0.0.1",
+		Service:  "common",
+		Version:  "v1.2.0",
+		Commit:   "abc123",
+	}
+}
+
+func TestNewLoggerWritesToLogFileWithDefaultFields(t *testing.T) {
+	logFields := newTestLoggingFields(t)
+	log, _, err := NewLogger(logFields)
+	assert.Nil(t, err)
+
+	log.Info("techno")
+	log.Sync()
+
+	contents, err := ioutil.ReadFile(logFields.LogPaths[1])
+	assert.Nil(t, err)
+	logLine := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(contents, &logLine))
+	assert.Equal(t, "techno", logLine["msg"])
+	assert.Equal(t, "testNode", logLine["nodeName"])
+	assert.Equal(t, "common", logLine["service"])
+	assert.Equal(t, "v1.2.0", logLine["version"])
+	assert.Equal(t, "abc123", logLine["commit"])
+}
+
+func TestNewLoggerRespectsLevel(t *testing.T) {
+	logFields := newTestLoggingFields(t)
+	log, level, err := NewLogger(logFields, WithLevel(zapcore.WarnLevel))
+	assert.Nil(t, err)
+	assert.Equal(t, zapcore.WarnLevel, level.Level())
+
+	log.Info("should not be written")
+	level.SetLevel(zapcore.InfoLevel)
+	log.Info("should be written")
+	log.Sync()
+
+	contents, err := ioutil.ReadFile(logFields.LogPaths[1])
+	assert.Nil(t, err)
+	assert.NotContains(t, string(contents), "should not be written")
+	assert.Contains(t, string(contents), "should be written")
+}
+
+func TestNewLoggerWithConsoleEncodingAndExtraSink(t *testing.T) {
+	logFields := newTestLoggingFields(t)
+	extraSink := filepath.Join(t.TempDir(), "extra.log")
+	log, _, err := NewLogger(logFields, WithEncoding("console"), WithSinks(extraSink), WithoutSampling())
+	assert.Nil(t, err)
+
+	log.Info("breakbeat")
+	log.Sync()
+
+	contents, err := ioutil.ReadFile(extraSink)
+	assert.Nil(t, err)
+	assert.Contains(t, string(contents), "breakbeat")
+	assert.False(t, strings.HasPrefix(string(contents), "{"), "console encoding should not be json")
+}
+
+func TestNewLoggerInDevelopmentModeDefaultsToDebug(t *testing.T) {
+	_, level, err := NewLogger(newTestLoggingFields(t), WithDevelopment())
+	assert.Nil(t, err)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
+
+func TestNewLoggerReturnsAnErrorForUnknownEncoding(t *testing.T) {
+	_, _, err := NewLogger(newTestLoggingFields(t), WithEncoding("xml"))
+	assert.Contains(t, err.Error(), "unknown log encoding")
+}
+
+func TestLogLevelHandlerChangesLevel(t *testing.T) {
+	_, level, err := NewLogger(newTestLoggingFields(t))
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level":"debug"}`))
+	res := httptest.NewRecorder()
+	LogLevelHandler(level).ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, zapcore.DebugLevel, level.Level())
+}
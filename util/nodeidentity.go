@@ -0,0 +1,175 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// NodeIdentity identifies the host a service is running on
+type NodeIdentity struct {
+	IP        net.IP
+	Interface string
+	Hostname  string
+}
+
+// Address returns the node's IP address, or its hostname when
+// no usable IP address could be found
+func (n NodeIdentity) Address() string {
+	if n.IP != nil {
+		return n.IP.String()
+	}
+	return n.Hostname
+}
+
+// interfaceAddr is an address assigned to a named network interface
+type interfaceAddr struct {
+	name string
+	ip   net.IP
+}
+
+// NodeIdentityResolver finds the address of the current node by enumerating
+// local network interfaces, no network access is needed. The result of the
+// first call to Resolve is cached
+type NodeIdentityResolver struct {
+	// Interface is the name of the preferred network interface e.g. eth0
+	Interface string
+	// CIDR is the preferred network e.g. 10.0.0.0/8
+	CIDR string
+	// PreferIPv6 picks IPv6 addresses over IPv4 addresses
+	PreferIPv6 bool
+
+	once     sync.Once
+	identity NodeIdentity
+	err      error
+
+	// listAddrs and hostname are replaced in tests
+	listAddrs func() ([]interfaceAddr, error)
+	hostname  func() (string, error)
+}
+
+// NewNodeIdentityResolverFromEnv creates a resolver configured with
+// NODE_INTERFACE, NODE_CIDR and NODE_PREFER_IPV6, all of which are optional
+func NewNodeIdentityResolverFromEnv() *NodeIdentityResolver {
+	preferIPv6, _ := strconv.ParseBool(os.Getenv("NODE_PREFER_IPV6"))
+	return &NodeIdentityResolver{
+		Interface:  os.Getenv("NODE_INTERFACE"),
+		CIDR:       os.Getenv("NODE_CIDR"),
+		PreferIPv6: preferIPv6,
+	}
+}
+
+var (
+	defaultNodeIdentityResolver     *NodeIdentityResolver
+	defaultNodeIdentityResolverOnce sync.Once
+)
+
+// ResolveNodeIdentity resolves the current node's identity using a resolver
+// configured from the environment, see NewNodeIdentityResolverFromEnv
+func ResolveNodeIdentity() (NodeIdentity, error) {
+	defaultNodeIdentityResolverOnce.Do(func() {
+		defaultNodeIdentityResolver = NewNodeIdentityResolverFromEnv()
+	})
+	return defaultNodeIdentityResolver.Resolve()
+}
+
+// Resolve returns the identity of the current node. Addresses in the
+// configured CIDR are preferred, then addresses on the configured interface,
+// then the preferred IP version. Loopback and link-local addresses are
+// ignored and the hostname is used when no other address is available
+func (r *NodeIdentityResolver) Resolve() (NodeIdentity, error) {
+	r.once.Do(func() {
+		r.identity, r.err = r.resolve()
+	})
+	return r.identity, r.err
+}
+
+func (r *NodeIdentityResolver) resolve() (NodeIdentity, error) {
+	var network *net.IPNet
+	if r.CIDR != "" {
+		_, parsedNetwork, err := net.ParseCIDR(r.CIDR)
+		if err != nil {
+			return NodeIdentity{}, MakeErr(err, fmt.Sprintf("invalid node CIDR %q", r.CIDR))
+		}
+		network = parsedNetwork
+	}
+
+	listAddrs := r.listAddrs
+	if listAddrs == nil {
+		listAddrs = listInterfaceAddrs
+	}
+	getHostname := r.hostname
+	if getHostname == nil {
+		getHostname = os.Hostname
+	}
+
+	identity := NodeIdentity{}
+	hostname, hostnameErr := getHostname()
+	if hostnameErr == nil {
+		identity.Hostname = hostname
+	}
+
+	addrs, err := listAddrs()
+	if err != nil && hostnameErr != nil {
+		return NodeIdentity{}, MakeErr(errors.New("could not list interfaces or get hostname"), err.Error())
+	}
+
+	best := -1
+	bestScore := -1
+	for i, addr := range addrs {
+		if addr.ip.IsLoopback() || addr.ip.IsLinkLocalUnicast() || addr.ip.IsUnspecified() {
+			continue
+		}
+		score := 0
+		if network != nil && network.Contains(addr.ip) {
+			score += 4
+		}
+		if r.Interface != "" && addr.name == r.Interface {
+			score += 2
+		}
+		if (addr.ip.To4() == nil) == r.PreferIPv6 {
+			score++
+		}
+		if score > bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+
+	if best == -1 {
+		if hostnameErr != nil {
+			return NodeIdentity{}, MakeErr(hostnameErr, "no usable interface address and could not get hostname")
+		}
+		return identity, nil
+	}
+	identity.IP = addrs[best].ip
+	identity.Interface = addrs[best].name
+	return identity, nil
+}
+
+// listInterfaceAddrs returns every address assigned to an interface that is up
+func listInterfaceAddrs() ([]interfaceAddr, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	addrs := []interfaceAddr{}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifaceAddrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				addrs = append(addrs, interfaceAddr{name: iface.Name, ip: ipNet.IP})
+			}
+		}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeNodeIdentityResolver(addrs []interfaceAddr) *NodeIdentityResolver {
+	return &NodeIdentityResolver{
+		listAddrs: func() ([]interfaceAddr, error) {
+			return addrs, nil
+		},
+		hostname: func() (string, error) {
+			return "neo-node-1", nil
+		},
+	}
+}
+
+var fakeInterfaceAddrs = []interfaceAddr{
+	{name: "lo", ip: net.ParseIP("127.0.0.1")},
+	{name: "eth0", ip: net.ParseIP("fe80::1")},
+	{name: "eth0", ip: net.ParseIP("192.168.1.20")},
+	{name: "eth0", ip: net.ParseIP("2001:db8::20")},
+	{name: "wg0", ip: net.ParseIP("10.8.0.3")},
+}
+
+func TestResolvePrefersIPv4ByDefault(t *testing.T) {
+	identity, err := newFakeNodeIdentityResolver(fakeInterfaceAddrs).Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.1.20", identity.Address())
+	assert.Equal(t, "eth0", identity.Interface)
+	assert.Equal(t, "neo-node-1", identity.Hostname)
+}
+
+func TestResolvePrefersConfiguredInterface(t *testing.T) {
+	resolver := newFakeNodeIdentityResolver(fakeInterfaceAddrs)
+	resolver.Interface = "wg0"
+
+	identity, err := resolver.Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "10.8.0.3", identity.Address())
+}
+
+func TestResolvePrefersConfiguredCIDR(t *testing.T) {
+	resolver := newFakeNodeIdentityResolver(fakeInterfaceAddrs)
+	resolver.Interface = "eth0"
+	resolver.CIDR = "10.0.0.0/8"
+
+	identity, err := resolver.Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "10.8.0.3", identity.Address())
+}
+
+func TestResolveWithIPv6Preference(t *testing.T) {
+	resolver := newFakeNodeIdentityResolver(fakeInterfaceAddrs)
+	resolver.PreferIPv6 = true
+
+	identity, err := resolver.Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8::20", identity.Address())
+}
+
+func TestResolveFallsBackToHostname(t *testing.T) {
+	resolver := newFakeNodeIdentityResolver(fakeInterfaceAddrs[:2])
+
+	identity, err := resolver.Resolve()
+
+	assert.Nil(t, err)
+	assert.Nil(t, identity.IP)
+	assert.Equal(t, "neo-node-1", identity.Address())
+}
+
+func TestResolveReturnsAnErrorWhenNothingIsAvailable(t *testing.T) {
+	resolver := &NodeIdentityResolver{
+		listAddrs: func() ([]interfaceAddr, error) {
+			return nil, errors.New("no interfaces")
+		},
+		hostname: func() (string, error) {
+			return "", errors.New("no hostname")
+		},
+	}
+
+	_, err := resolver.Resolve()
+
+	assert.Error(t, err)
+}
+
+func TestResolveReturnsAnErrorForInvalidCIDR(t *testing.T) {
+	resolver := newFakeNodeIdentityResolver(fakeInterfaceAddrs)
+	resolver.CIDR = "techno"
+
+	_, err := resolver.Resolve()
+
+	assert.Contains(t, err.Error(), "invalid node CIDR")
+}
+
+func TestResolveCachesTheResult(t *testing.T) {
+	calls := 0
+	resolver := &NodeIdentityResolver{
+		listAddrs: func() ([]interfaceAddr, error) {
+			calls++
+			return fakeInterfaceAddrs, nil
+		},
+		hostname: func() (string, error) {
+			return "neo-node-1", nil
+		},
+	}
+
+	first, _ := resolver.Resolve()
+	second, _ := resolver.Resolve()
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first, second)
+}
@@ -0,0 +1,213 @@
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedPlaceholder replaces any sensitive data removed from logs
+const RedactedPlaceholder = "[REDACTED]"
+
+// DefaultRedactPatterns match steam API keys and other credentials passed in
+// query strings as well as bearer tokens. The first capture group of each
+// pattern is kept so only the secret itself is replaced
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)([?&](?:key|apikey|api_key|authkey|auth_key|access_token|token)=)[^&#\s"']+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`),
+}
+
+// DefaultRedactKeys are log field and struct field names whose values are
+// always redacted, such as Player.Realname and auth headers
+var DefaultRedactKeys = []string{
+	"realname",
+	"authentication",
+	"authorization",
+	"auth_key",
+	"authkey",
+	"apikey",
+}
+
+// RedactorConfig configures what a Redactor scrubs
+type RedactorConfig struct {
+	// Patterns are replaced wherever they match in a string, if a pattern
+	// has capture groups the first group is kept
+	Patterns []*regexp.Regexp
+	// Keys are field names (case insensitive) whose values are always
+	// replaced regardless of their content
+	Keys []string
+	// Secrets are literal values, such as the AUTH_KEY, that are replaced
+	// wherever they appear
+	Secrets []string
+}
+
+// Redactor scrubs sensitive data from strings and zap fields
+type Redactor struct {
+	patterns []*regexp.Regexp
+	keys     map[string]bool
+	secrets  []string
+}
+
+// NewRedactor creates a Redactor from the given config
+func NewRedactor(config RedactorConfig) *Redactor {
+	r := &Redactor{
+		patterns: config.Patterns,
+		keys:     make(map[string]bool),
+	}
+	for _, key := range config.Keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+	for _, secret := range config.Secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// DefaultRedactor redacts the DefaultRedactPatterns, DefaultRedactKeys and
+// the value of AUTH_KEY if it is set
+func DefaultRedactor() *Redactor {
+	return NewRedactor(RedactorConfig{
+		Patterns: DefaultRedactPatterns,
+		Keys:     DefaultRedactKeys,
+		Secrets:  []string{os.Getenv("AUTH_KEY")},
+	})
+}
+
+// String returns s with every secret and pattern match replaced
+func (r *Redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, RedactedPlaceholder)
+	}
+	for _, pattern := range r.patterns {
+		if pattern.NumSubexp() > 0 {
+			s = pattern.ReplaceAllString(s, "${1}"+RedactedPlaceholder)
+		} else {
+			s = pattern.ReplaceAllLiteralString(s, RedactedPlaceholder)
+		}
+	}
+	return s
+}
+
+// IsRedactedKey determines if values stored under a given field
+// name should always be redacted
+func (r *Redactor) IsRedactedKey(key string) bool {
+	return r.keys[strings.ToLower(key)]
+}
+
+// Field returns a copy of a zap field with sensitive data removed. Structured
+// fields (objects, arrays and reflected structs) are flattened into maps so
+// that nested keys such as Player.Realname can be redacted
+func (r *Redactor) Field(f zapcore.Field) zapcore.Field {
+	if r.IsRedactedKey(f.Key) {
+		return zap.String(f.Key, RedactedPlaceholder)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.String(f.String))
+	case zapcore.ByteStringType:
+		return zap.String(f.Key, r.String(string(f.Interface.([]byte))))
+	case zapcore.ErrorType:
+		return zap.String(f.Key, r.String(f.Interface.(error).Error()))
+	case zapcore.ReflectType:
+		raw, err := json.Marshal(f.Interface)
+		if err != nil {
+			return zap.String(f.Key, RedactedPlaceholder)
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return zap.String(f.Key, RedactedPlaceholder)
+		}
+		return zap.Any(f.Key, r.value(value))
+	case zapcore.StringerType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return zap.Any(f.Key, r.value(enc.Fields[f.Key]))
+	}
+	return f
+}
+
+// Fields redacts each field in fields
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = r.Field(f)
+	}
+	return redacted
+}
+
+func (r *Redactor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if r.IsRedactedKey(key) {
+				redacted[key] = RedactedPlaceholder
+				continue
+			}
+			redacted[key] = r.value(val)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, val := range v {
+			redacted[i] = r.value(val)
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactingCore scrubs log messages and fields before handing
+// them to the wrapped core
+type redactingCore struct {
+	zapcore.Core
+	redactor *Redactor
+}
+
+// NewRedactingCore wraps core so that every message and field written
+// through it has been passed through the redactor
+func NewRedactingCore(core zapcore.Core, redactor *Redactor) zapcore.Core {
+	return &redactingCore{
+		Core:     core,
+		redactor: redactor,
+	}
+}
+
+// WithRedaction scrubs sensitive data from every log written by the logger
+func WithRedaction(redactor *Redactor) LoggerOption {
+	return WithZapOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewRedactingCore(core, redactor)
+	}))
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{
+		Core:     c.Core.With(c.redactor.Fields(fields)),
+		redactor: c.redactor,
+	}
+}
+
+func (c *redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	// Let the wrapped core (e.g. a sampler) decide if the entry is written
+	if c.Core.Check(ent, nil) == nil {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.String(ent.Message)
+	return c.Core.Write(ent, c.redactor.Fields(fields))
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"syscall"
+	"testing"
+
+	"github.com/neosteamfriendgraphing/common"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const fakeSteamAPIKey = "ABCDEF0123456789ABCDEF0123456789"
+
+func TestRedactorStringRemovesAPIKeyFromURL(t *testing.T) {
+	URL := fmt.Sprintf("https://api.steampowered.com/ISteamUser/GetFriendList/v0001/?key=%s&steamid=76561197969081524", fakeSteamAPIKey)
+
+	redacted := DefaultRedactor().String(URL)
+
+	assert.NotContains(t, redacted, fakeSteamAPIKey)
+	assert.Contains(t, redacted, "?key=[REDACTED]&steamid=76561197969081524")
+}
+
+func TestRedactorStringRemovesBearerTokens(t *testing.T) {
+	redacted := DefaultRedactor().String("Authorization: Bearer abc.def-ghi")
+
+	assert.Equal(t, "Authorization: Bearer [REDACTED]", redacted)
+}
+
+func TestRedactorStringRemovesSecretsAndCustomPatterns(t *testing.T) {
+	redactor := NewRedactor(RedactorConfig{
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{17}`)},
+		Secrets:  []string{"anycans"},
+	})
+
+	redacted := redactor.String("anycans 76561197969081524")
+
+	assert.Equal(t, "[REDACTED] [REDACTED]", redacted)
+}
+
+func TestRedactorFieldRedactsStructFields(t *testing.T) {
+	player := common.Player{
+		Steamid:     "76561197969081524",
+		Personaname: "anne",
+		Realname:    "Anne Example",
+	}
+
+	field := DefaultRedactor().Field(zap.Any("player", player))
+
+	redactedPlayer := field.Interface.(map[string]interface{})
+	assert.Equal(t, RedactedPlaceholder, redactedPlayer["realname"])
+	assert.Equal(t, "anne", redactedPlayer["personaname"])
+}
+
+func TestGetAndReadErrorDoesNotLeakAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, err := GetAndRead(fmt.Sprintf("%s/?key=%s", server.URL, fakeSteamAPIKey), []http.Header{})
+
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), fakeSteamAPIKey)
+	assert.Contains(t, err.Error(), "util.go:")
+	urlErr := &url.Error{}
+	assert.True(t, errors.As(err, &urlErr))
+	assert.True(t, errors.Is(err, syscall.ECONNREFUSED))
+}
+
+func TestRedactingLoggerNeverWritesSecrets(t *testing.T) {
+	os.Setenv("AUTH_KEY", "techno")
+	defer os.Setenv("AUTH_KEY", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	URL := fmt.Sprintf("%s/?key=%s", server.URL, fakeSteamAPIKey)
+	_, err := GetAndRead(URL, []http.Header{{"Authentication": []string{"techno"}}})
+	assert.Nil(t, err)
+	server.Close()
+	_, getErr := GetAndRead(URL, []http.Header{})
+
+	logFields := newTestLoggingFields(t)
+	log, _, err := NewLogger(logFields, WithRedaction(DefaultRedactor()))
+	assert.Nil(t, err)
+
+	log.Info("calling "+URL,
+		zap.String("url", URL),
+		zap.Error(getErr),
+		zap.NamedError("wrapped", errors.New("failed: "+URL)),
+		zap.String("authentication", "techno"),
+		zap.Any("player", common.Player{Realname: "Anne Example"}))
+	log.With(zap.String("url", URL)).Warn("retrying")
+	log.Sync()
+
+	contents, err := ioutil.ReadFile(logFields.LogPaths[1])
+	assert.Nil(t, err)
+	assert.NotContains(t, string(contents), fakeSteamAPIKey)
+	assert.NotContains(t, string(contents), "techno")
+	assert.NotContains(t, string(contents), "Anne Example")
+	assert.Contains(t, string(contents), "retrying")
+}
@@ -5,9 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"runtime"
@@ -16,9 +15,14 @@ import (
 	"time"
 
 	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/buildinfo"
 	"go.uber.org/zap"
 )
 
+// RequestIDHeader carries the ID of the request that caused a call so
+// that logs can be followed across services
+const RequestIDHeader = "X-Request-ID"
+
 // IsValidFormatSteamID determines if a string is a valid
 // format steam64ID (17 numerical digits)
 func IsValidFormatSteamID(steamID string) bool {
@@ -30,25 +34,29 @@ func IsValidFormatSteamID(steamID string) bool {
 }
 
 // GetLocalIPAddress retrieves the local IP (port not included) for the current
-// system as this is used in logs for quick access
+// system as this is used in logs for quick access. The hostname is returned
+// when there is no usable IP and an empty string if neither can be found,
+// see ResolveNodeIdentity
 func GetLocalIPAddress() string {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
+	identity, err := ResolveNodeIdentity()
 	if err != nil {
-		log.Fatal(err)
+		return ""
 	}
-	defer conn.Close()
-	addrWithNoPort := strings.Split(conn.LocalAddr().(*net.UDPAddr).String(), ":")
-	return addrWithNoPort[0]
+	return identity.Address()
 }
 
 // LoadLoggingConfig loads required config from .env that are default logging fields
+// along with the version and commit the service was built from
 func LoadLoggingConfig() (common.LoggingFields, error) {
+	buildInfo := buildinfo.Get()
 	logFieldsConfig := common.LoggingFields{
 		NodeName: os.Getenv("NODE_NAME"),
 		NodeDC:   os.Getenv("NODE_DC"),
 		LogPaths: []string{"stdout", os.Getenv("LOG_PATH")},
 		NodeIPV4: GetLocalIPAddress(),
 		Service:  os.Getenv("SERVICE"),
+		Version:  buildInfo.Version,
+		Commit:   buildInfo.Commit,
 	}
 	if logFieldsConfig.NodeName == "" || logFieldsConfig.NodeDC == "" ||
 		logFieldsConfig.LogPaths[1] == "" || logFieldsConfig.NodeIPV4 == "" {
@@ -59,20 +67,10 @@ func LoadLoggingConfig() (common.LoggingFields, error) {
 }
 
 // InitLogger Initialises the zap logger and returns a pointer to an instance of it,
-// this also involves creating the logfile specified by LOG_PATH
+// this also involves creating the logfile specified by LOG_PATH. Use NewLogger
+// to configure the logger or to handle errors without panicking
 func InitLogger(logFieldsConfig common.LoggingFields) *zap.Logger {
-	os.OpenFile(logFieldsConfig.LogPaths[1], os.O_RDONLY|os.O_CREATE, 0666)
-	c := zap.NewProductionConfig()
-	c.OutputPaths = []string{"stdout", logFieldsConfig.LogPaths[1]}
-
-	globalLogFields := make(map[string]interface{})
-	globalLogFields["nodeName"] = logFieldsConfig.NodeName
-	globalLogFields["nodeDC"] = logFieldsConfig.NodeDC
-	globalLogFields["nodeIPV4"] = logFieldsConfig.NodeIPV4
-	globalLogFields["service"] = logFieldsConfig.Service
-	c.InitialFields = globalLogFields
-
-	log, err := c.Build()
+	log, _, err := NewLogger(logFieldsConfig)
 	if err != nil {
 		panic(err)
 	}
@@ -119,12 +117,13 @@ func SendBasicErrorResponse(w http.ResponseWriter, req *http.Request, err error,
 }
 
 // GetAndRead executes a HTTP GET request and returns the body
-// of the response in []byte format or an error if it's not nil
+// of the response in []byte format or an error if it's not nil.
+// Errors have API keys in the URL redacted
 func GetAndRead(URL string, headers []http.Header) ([]byte, error) {
 	client := &http.Client{}
 	req, err := http.NewRequest("GET", URL, nil)
 	if err != nil {
-		return []byte{}, MakeErr(err)
+		return []byte{}, MakeErr(redactURLError(err), "could not create request")
 	}
 	for _, header := range headers {
 		for key, val := range header {
@@ -133,7 +132,7 @@ func GetAndRead(URL string, headers []http.Header) ([]byte, error) {
 	}
 	res, err := client.Do(req)
 	if err != nil {
-		return []byte{}, MakeErr(err)
+		return []byte{}, MakeErr(redactURLError(err), "GET request failed")
 	}
 
 	body, err := ioutil.ReadAll(res.Body)
@@ -145,6 +144,20 @@ func GetAndRead(URL string, headers []http.Header) ([]byte, error) {
 	return body, nil
 }
 
+// redactURLError redacts the URL of a *url.Error, which net/http
+// includes in its errors, keeping the rest of the error chain
+func redactURLError(err error) error {
+	urlErr := &url.Error{}
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{
+		Op:  urlErr.Op,
+		URL: DefaultRedactor().String(urlErr.URL),
+		Err: urlErr.Err,
+	}
+}
+
 func EnsureAllEnvVarsAreSet(serviceSpecificEnvVars ...string) error {
 	resultString := ""
 
@@ -202,7 +215,7 @@ func SetupCORS(w *http.ResponseWriter, req *http.Request) {
 }
 
 // MakeErr creates an error with a trace to where this function was
-// called from
+// called from, wrapping err
 // 		errorWithLineTrace := MakeErr(err, "heres an error that was thrown because of x, y, z...")
 func MakeErr(err error, msg ...string) error {
 	if err == nil {
@@ -211,9 +224,9 @@ func MakeErr(err error, msg ...string) error {
 	_, file, line, _ := runtime.Caller(1)
 	path, _ := os.Getwd()
 	if len(msg) > 0 {
-		return fmt.Errorf("%s:%d %s: %s", strings.TrimPrefix(file, path), line, msg[0], err)
+		return fmt.Errorf("%s:%d %s: %w", strings.TrimPrefix(file, path), line, msg[0], err)
 	}
-	return fmt.Errorf("%s:%d : %s", strings.TrimPrefix(file, path), line, err)
+	return fmt.Errorf("%s:%d : %w", strings.TrimPrefix(file, path), line, err)
 }
 
 func GetBaseURLPath(r *http.Request) string {
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/neosteamfriendgraphing/common"
+	"github.com/neosteamfriendgraphing/common/buildinfo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,6 +38,8 @@ func TestLoadLoggingConfigWithSpecifiedVariables(t *testing.T) {
 		LogPaths: []string{"stdout", "expectedLogPath"},
 		NodeIPV4: GetLocalIPAddress(),
 		Service:  "common",
+		Version:  buildinfo.Get().Version,
+		Commit:   buildinfo.Get().Commit,
 	}
 
 	os.Setenv("NODE_NAME", "expectedName")
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the largest request body DecodeAndValidate
+// accepts when no limit is given
+const DefaultMaxBodySize = 1 << 20
+
+// errBodyTooLarge is returned when reading more than the limit of a body
+var errBodyTooLarge = errors.New("request body too large")
+
+// Validator is implemented by input DTOs that can check their own fields.
+// Validate may also normalise the input, such as removing duplicate IDs
+type Validator interface {
+	Validate() error
+}
+
+// StrictDecoder is implemented by input DTOs with a custom UnmarshalJSON,
+// which DisallowUnknownFields doesn't reach, so that DecodeAndValidate can
+// still reject unknown fields. decoder already disallows unknown fields
+type StrictDecoder interface {
+	DecodeStrict(decoder *json.Decoder) error
+}
+
+// FieldError is a single invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// ValidationErrors is every invalid field of a request
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Add records that a field is invalid
+func (e *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns the errors as an error, or nil if there are none
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// invalidInputResponse is the standard invalid response with
+// the individual field errors when validation failed
+type invalidInputResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// DecodeAndValidate decodes a JSON request body into dst and validates it.
+// Bodies larger than maxBodySize bytes (DefaultMaxBodySize if 0), with
+// unknown fields or with more than one JSON value are rejected. On failure
+// an invalid response is written and the error returned, so handlers only
+// need to return
+//
+//	input := dtos.CrawlUsersInputDTO{}
+//	if err := util.DecodeAndValidate(w, req, &input, 0); err != nil {
+//		return
+//	}
+func DecodeAndValidate(w http.ResponseWriter, req *http.Request, dst Validator, maxBodySize int64) error {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	err := decodeAndValidate(w, req, dst, maxBodySize)
+	if err == nil {
+		return nil
+	}
+
+	response := invalidInputResponse{Error: err.Error()}
+	statusCode := http.StatusBadRequest
+	var validationErrs ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		response.Error = "invalid input"
+		response.Fields = validationErrs
+	case errors.Is(err, errBodyTooLarge):
+		response.Error = fmt.Sprintf("request body must not be larger than %d bytes", maxBodySize)
+		statusCode = http.StatusRequestEntityTooLarge
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+	return err
+}
+
+func decodeAndValidate(w http.ResponseWriter, req *http.Request, dst Validator, maxBodySize int64) error {
+	if req.Body == nil {
+		return errors.New("request body must not be empty")
+	}
+	body := &limitedBody{r: http.MaxBytesReader(w, req.Body, maxBodySize), limit: maxBodySize}
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	decode := func() error { return decoder.Decode(dst) }
+	if strict, ok := dst.(StrictDecoder); ok {
+		decode = func() error { return strict.DecodeStrict(decoder) }
+	}
+	if err := decode(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("request body must not be empty")
+		}
+		if errors.Is(err, errBodyTooLarge) {
+			return err
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		if errors.Is(err, errBodyTooLarge) {
+			return err
+		}
+		return errors.New("request body must only contain a single JSON object")
+	}
+	return dst.Validate()
+}
+
+// limitedBody reads from an http.MaxBytesReader, returning errBodyTooLarge
+// instead of its error once the limit is reached so the error can be told
+// apart from others without matching on its text
+type limitedBody struct {
+	r     io.Reader
+	read  int64
+	limit int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) && b.read >= b.limit {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testInput struct {
+	SteamID string `json:"steamid"`
+}
+
+func (input *testInput) Validate() error {
+	errs := ValidationErrors{}
+	if !IsValidFormatSteamID(input.SteamID) {
+		errs.Add("steamid", "must be a 17 digit steamID")
+	}
+	return errs.Err()
+}
+
+func decodeTestInput(body string, maxBodySize int64) (*httptest.ResponseRecorder, testInput, error) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(body))
+	input := testInput{}
+	err := DecodeAndValidate(w, req, &input, maxBodySize)
+	return w, input, err
+}
+
+func TestDecodeAndValidateWithValidInput(t *testing.T) {
+	w, input, err := decodeTestInput(`{"steamid": "76561197969081524"}`, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "76561197969081524", input.SteamID)
+	assert.Equal(t, 0, w.Body.Len())
+}
+
+func TestDecodeAndValidateWritesFieldErrors(t *testing.T) {
+	w, _, err := decodeTestInput(`{"steamid": "1234"}`, 0)
+
+	assert.Equal(t, ValidationErrors{{Field: "steamid", Message: "must be a 17 digit steamID"}}, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error": "invalid input", "fields": [{"field": "steamid", "message": "must be a 17 digit steamID"}]}`, w.Body.String())
+}
+
+func TestDecodeAndValidateRejectsInvalidBodies(t *testing.T) {
+	tests := map[string]string{
+		``:               "request body must not be empty",
+		`{"steamid": 1}`: "invalid request body: json: cannot unmarshal number into Go struct field testInput.steamid of type string",
+		`{"steamid": "76561197969081524", "level": 1}`: `invalid request body: json: unknown field "level"`,
+		`{"steamid": "76561197969081524"} {}`:          "request body must only contain a single JSON object",
+	}
+	for body, expected := range tests {
+		w, _, err := decodeTestInput(body, 0)
+
+		assert.EqualError(t, err, expected, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		response := map[string]string{}
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, expected, response["error"], body)
+	}
+}
+
+func TestDecodeAndValidateRejectsLargeBodies(t *testing.T) {
+	w, _, err := decodeTestInput(`{"steamid": "76561197969081524"}`, 10)
+
+	assert.ErrorIs(t, err, errBodyTooLarge)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.JSONEq(t, `{"error": "request body must not be larger than 10 bytes"}`, w.Body.String())
+
+	body := `{"steamid": "76561197969081524"}`
+	_, _, err = decodeTestInput(body, int64(len(body)))
+	assert.Nil(t, err)
+}
+
+func TestValidationErrors(t *testing.T) {
+	errs := ValidationErrors{}
+	assert.Nil(t, errs.Err())
+
+	errs.Add("level", "must be between %d and %d", 1, 3)
+	errs.Add("steamid", "is required")
+
+	assert.EqualError(t, errs.Err(), "level must be between 1 and 3, steamid is required")
+}